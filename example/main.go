@@ -5,7 +5,6 @@ import (
 	"github.com/sujit-baniya/protocol/smpp"
 	"github.com/sujit-baniya/protocol/smpp/coding"
 	"log"
-	"strings"
 	"sync"
 	"time"
 
@@ -31,6 +30,15 @@ func sendingAndReceiveSMS(wg *sync.WaitGroup) {
 		SystemType: "",
 	}
 
+	reassembler := smpp.NewReassembler(smpp.ReassemblerSettings{
+		OnMessage: func(m smpp.InboundMessage) {
+			log.Println(m.Text)
+		},
+	})
+	defer func() {
+		_ = reassembler.Close()
+	}()
+
 	trans, err := smpp.NewSession(
 		smpp.TRXConnector(smpp.NonTLSDialer, auth),
 		smpp.Settings{
@@ -50,7 +58,7 @@ func sendingAndReceiveSMS(wg *sync.WaitGroup) {
 				fmt.Println("Rebinding but error:", err)
 			},
 
-			OnPDU: handlePDU(),
+			OnPDU: reassembler.OnPDU(handlePDU()),
 
 			OnClosed: func(state smpp.State) {
 				fmt.Println(state)
@@ -73,7 +81,6 @@ func sendingAndReceiveSMS(wg *sync.WaitGroup) {
 }
 
 func handlePDU() func(pdu.PDU, bool) {
-	concatenated := map[uint8][]string{}
 	return func(p pdu.PDU, _ bool) {
 		switch pd := p.(type) {
 		case *pdu.SubmitSMResp:
@@ -90,26 +97,6 @@ func handlePDU() func(pdu.PDU, bool) {
 
 		case *pdu.DeliverSM:
 			fmt.Printf("DeliverSM:%+v\n", pd)
-			log.Println(pd.Message.GetMessage())
-			// region concatenated sms (sample code)
-			message, err := pd.Message.GetMessage()
-			if err != nil {
-				log.Fatal(err)
-			}
-			totalParts, sequence, reference, found := pd.Message.UDH().GetConcatInfo()
-			if found {
-				if _, ok := concatenated[reference]; !ok {
-					concatenated[reference] = make([]string, totalParts)
-				}
-				concatenated[reference][sequence-1] = message
-			}
-			if !found {
-				log.Println(message)
-			} else if parts, ok := concatenated[reference]; ok && isConcatenatedDone(parts, totalParts) {
-				log.Println(strings.Join(parts, ""))
-				delete(concatenated, reference)
-			}
-			// endregion
 		}
 	}
 }
@@ -137,12 +124,3 @@ func newSubmitSM() *pdu.SubmitSM {
 
 	return submitSM
 }
-
-func isConcatenatedDone(parts []string, total byte) bool {
-	for _, part := range parts {
-		if part != "" {
-			total--
-		}
-	}
-	return total == 0
-}
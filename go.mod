@@ -4,6 +4,7 @@ go 1.18
 
 require (
 	github.com/go-errors/errors v1.4.1
+	github.com/rs/xid v1.4.0
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/text v0.3.7
 	golang.org/x/time v0.0.0-20211116232009-f0f3c7e86c11
@@ -12,6 +13,5 @@ require (
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/rs/xid v1.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -0,0 +1,249 @@
+package smpp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"golang.org/x/time/rate"
+)
+
+// ThrottlingStatuses are command statuses by which SMSC asks to slow down.
+var ThrottlingStatuses = []data.CommandStatusType{data.ESME_RTHROTTLED, data.ESME_RMSGQFUL}
+
+// DefaultMaxRequeues is number of times a throttled request is requeued if
+// AdaptiveRateSettings.MaxRequeues is zero.
+const DefaultMaxRequeues = 5
+
+// AdaptiveRateSettings controls rate of message submission (submit_sm, submit_multi, data_sm)
+// by responses of SMSC: the rate is cut multiplicatively once throttling status arrives and
+// increased additively while messages are accepted.
+//
+// Adaptive rate applies on top of Settings.RateLimiter and survives rebinding of Session.
+type AdaptiveRateSettings struct {
+	// MaxRate is the highest rate in messages per second. Required.
+	MaxRate rate.Limit
+
+	// MinRate is the lowest rate in messages per second. Zero defaults to one message per second.
+	MinRate rate.Limit
+
+	// InitialRate is starting rate in messages per second. Zero starts at MaxRate.
+	InitialRate rate.Limit
+
+	// Increase is added to the rate per second of accepted messages. Zero defaults to 1.
+	Increase rate.Limit
+
+	// Decrease multiplies the rate once throttled, within (0, 1). Zero defaults to 0.5.
+	Decrease float64
+
+	// Cooldown is minimum interval between two cuts, so that a burst of throttled
+	// responses to messages sent at the same rate cuts it only once. Zero defaults to one second.
+	Cooldown time.Duration
+
+	// Statuses cut the rate. Nil defaults to ThrottlingStatuses.
+	Statuses []data.CommandStatusType
+
+	// Requeue resubmits throttled message sent via Submit instead of passing the response to OnPDU,
+	// at most MaxRequeues times. Messages sent via SubmitResp or SubmitAsync get the response as is.
+	Requeue bool
+
+	// RequeueDelay is waited before requeued message is resubmitted.
+	RequeueDelay time.Duration
+
+	// MaxRequeues limits requeueing of a message. Zero defaults to DefaultMaxRequeues.
+	MaxRequeues int
+
+	// OnRateChange notifies rate cut by throttling status.
+	OnRateChange func(limit rate.Limit)
+}
+
+// adaptiveRate is AIMD controller of message rate.
+type adaptiveRate struct {
+	settings AdaptiveRateSettings
+	limiter  *rate.Limiter
+
+	mu      sync.Mutex
+	limit   rate.Limit
+	lastCut time.Time
+}
+
+func newAdaptiveRate(settings AdaptiveRateSettings) *adaptiveRate {
+	if settings.MinRate <= 0 {
+		settings.MinRate = 1
+	}
+	if settings.MaxRate < settings.MinRate {
+		settings.MaxRate = settings.MinRate
+	}
+	if settings.InitialRate <= 0 || settings.InitialRate > settings.MaxRate {
+		settings.InitialRate = settings.MaxRate
+	}
+	if settings.InitialRate < settings.MinRate {
+		settings.InitialRate = settings.MinRate
+	}
+	if settings.Increase <= 0 {
+		settings.Increase = 1
+	}
+	if settings.Decrease <= 0 || settings.Decrease >= 1 {
+		settings.Decrease = 0.5
+	}
+	if settings.Cooldown <= 0 {
+		settings.Cooldown = time.Second
+	}
+	if settings.Statuses == nil {
+		settings.Statuses = ThrottlingStatuses
+	}
+	if settings.MaxRequeues <= 0 {
+		settings.MaxRequeues = DefaultMaxRequeues
+	}
+
+	return &adaptiveRate{
+		settings: settings,
+		limiter:  rate.NewLimiter(settings.InitialRate, 1),
+		limit:    settings.InitialRate,
+	}
+}
+
+// current returns current rate.
+func (a *adaptiveRate) current() rate.Limit {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit
+}
+
+// throttled returns true if status asks to slow down.
+func (a *adaptiveRate) throttled(status data.CommandStatusType) bool {
+	for _, s := range a.settings.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// observe adjusts the rate by response to message. Returns true if the message is throttled.
+func (a *adaptiveRate) observe(status data.CommandStatusType) (throttled bool) {
+	throttled = a.throttled(status)
+	if !throttled && status != data.ESME_ROK {
+		return
+	}
+
+	a.mu.Lock()
+	limit := a.limit
+	switch {
+	case throttled:
+		if now := time.Now(); now.Sub(a.lastCut) >= a.settings.Cooldown {
+			a.lastCut = now
+			limit *= rate.Limit(a.settings.Decrease)
+		}
+
+	default:
+		// each accepted message adds its share, so that a second of accepted messages adds Increase
+		limit += a.settings.Increase / limit
+	}
+
+	if limit < a.settings.MinRate {
+		limit = a.settings.MinRate
+	}
+	if limit > a.settings.MaxRate {
+		limit = a.settings.MaxRate
+	}
+
+	changed := limit != a.limit
+	a.limit = limit
+	a.mu.Unlock()
+
+	if changed {
+		a.limiter.SetLimit(limit)
+		if throttled && a.settings.OnRateChange != nil {
+			a.settings.OnRateChange(limit)
+		}
+	}
+	return
+}
+
+// isMessage returns true if rate of p is controlled, see AdaptiveRateSettings.
+func isMessage(p pdu.PDU) bool {
+	switch p.GetHeader().CommandID {
+	case data.SUBMIT_SM, data.SUBMIT_MULTI, data.DATA_SM:
+		return true
+	default:
+		return false
+	}
+}
+
+// isMessageResponse returns true if p responds to message, see isMessage.
+func isMessageResponse(p pdu.PDU) bool {
+	switch p.GetHeader().CommandID {
+	case data.SUBMIT_SM_RESP, data.SUBMIT_MULTI_RESP, data.DATA_SM_RESP:
+		return true
+	default:
+		return false
+	}
+}
+
+// throttle adjusts adaptive rate by response of SMSC. Returns true if the response is
+// consumed by requeueing its request.
+func (t *transceivable) throttle(resp pdu.PDU) bool {
+	if t.adaptive == nil || !isMessageResponse(resp) {
+		return false
+	}
+
+	status := resp.GetHeader().CommandStatus
+	if !t.adaptive.observe(status) {
+		return false
+	}
+	t.logger.Warn("throttled by SMSC", "command_status", status.String(), "rate", float64(t.adaptive.current()))
+
+	if !t.adaptive.settings.Requeue {
+		return false
+	}
+
+	sequence := resp.GetSequenceNumber()
+	t.mutex.Lock()
+	r, ok := t.pending[sequence]
+	if !ok || r.result != nil || r.requeues >= t.adaptive.settings.MaxRequeues {
+		t.mutex.Unlock()
+		return false
+	}
+	delete(t.pending, sequence)
+	if len(t.pending) == 0 {
+		signal(t.idle)
+	}
+	t.mutex.Unlock()
+
+	r.requeues++
+	t.endRequestSpan(r, resp, nil)
+	go t.resubmit(r)
+	return true
+}
+
+// resubmit requeued request after AdaptiveRateSettings.RequeueDelay. Request which can not be
+// resubmitted is handed over to its lost callback, or reported to OnSubmitError.
+func (t *transceivable) resubmit(r *pendingRequest) {
+	err := sleep(t.ctx, t.adaptive.settings.RequeueDelay)
+	if err != nil || t.isDraining() {
+		err = ErrConnectionClosing
+	}
+
+	if err == nil {
+		t.conn.AssignSequenceNumber(r.req)
+		sequence := r.req.GetSequenceNumber()
+
+		if err = t.register(t.ctx, r); err == nil {
+			if err = t.submit(t.ctx, r.req); err == nil {
+				return
+			}
+			if _, ok := t.complete(sequence, nil, err); !ok {
+				// already handed over to lost by closing transceiver
+				return
+			}
+		}
+	}
+
+	if r.lost != nil {
+		r.lost(r.req)
+	} else {
+		t.onSubmitError(r.req, err)
+	}
+}
@@ -0,0 +1,163 @@
+package smpp
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestAdaptiveRate(t *testing.T) {
+	var cuts []rate.Limit
+	a := newAdaptiveRate(AdaptiveRateSettings{
+		MaxRate:      100,
+		MinRate:      10,
+		InitialRate:  40,
+		Increase:     20,
+		Cooldown:     time.Hour,
+		OnRateChange: func(limit rate.Limit) { cuts = append(cuts, limit) },
+	})
+	require.Equal(t, rate.Limit(40), a.current())
+
+	require.True(t, a.observe(data.ESME_RTHROTTLED))
+	require.Equal(t, rate.Limit(20), a.current())
+	require.Equal(t, rate.Limit(20), a.limiter.Limit())
+
+	// within cooldown
+	require.True(t, a.observe(data.ESME_RMSGQFUL))
+	require.Equal(t, rate.Limit(20), a.current())
+
+	// second of accepted messages adds Increase
+	for i := 0; i < 20; i++ {
+		require.False(t, a.observe(data.ESME_ROK))
+	}
+	require.InDelta(t, 35, float64(a.current()), 1)
+
+	// other statuses do not matter
+	require.False(t, a.observe(data.ESME_RINVDSTADR))
+	require.InDelta(t, 35, float64(a.current()), 1)
+
+	for i := 0; i < 1000; i++ {
+		a.observe(data.ESME_ROK)
+	}
+	require.Equal(t, rate.Limit(100), a.current())
+
+	a.lastCut = time.Time{}
+	a.settings.Decrease = 0.01
+	a.observe(data.ESME_RTHROTTLED)
+	require.Equal(t, rate.Limit(10), a.current())
+	require.Equal(t, []rate.Limit{20, 10}, cuts)
+
+	a = newAdaptiveRate(AdaptiveRateSettings{MaxRate: 5})
+	require.Equal(t, rate.Limit(5), a.current())
+	require.Equal(t, DefaultMaxRequeues, a.settings.MaxRequeues)
+	require.Equal(t, ThrottlingStatuses, a.settings.Statuses)
+}
+
+func TestSessionAdaptiveRate(t *testing.T) {
+	newServer := func(throttled int32) *smsctest.Server {
+		var submitted int32
+		return smsctest.NewServer(smsctest.Config{
+			SubmitStatus: func(pdu.PDU) data.CommandStatusType {
+				if atomic.AddInt32(&submitted, 1) <= throttled {
+					return data.ESME_RTHROTTLED
+				}
+				return data.ESME_ROK
+			},
+		})
+	}
+
+	type received struct {
+		mu       sync.Mutex
+		statuses []data.CommandStatusType
+	}
+	onPDU := func(r *received) PDUCallback {
+		return func(p pdu.PDU, _ bool) {
+			if p.GetHeader().CommandID == data.SUBMIT_SM_RESP {
+				r.mu.Lock()
+				r.statuses = append(r.statuses, p.GetHeader().CommandStatus)
+				r.mu.Unlock()
+			}
+		}
+	}
+
+	t.Run("requeue", func(t *testing.T) {
+		smsc := newServer(2)
+		defer smsc.Close()
+
+		var r received
+		var cuts int32
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout: time.Second,
+			OnPDU:       onPDU(&r),
+			AdaptiveRate: &AdaptiveRateSettings{
+				MaxRate:      1000,
+				MinRate:      100,
+				Cooldown:     time.Millisecond,
+				Requeue:      true,
+				RequeueDelay: 10 * time.Millisecond,
+				OnRateChange: func(rate.Limit) { atomic.AddInt32(&cuts, 1) },
+			},
+		}, 0)
+		require.NoError(t, err)
+		defer func() {
+			_ = session.Close()
+		}()
+
+		require.NoError(t, session.Transceiver().Submit(newSubmitSM("esme")))
+		require.Eventually(t, func() bool {
+			return len(smsc.ReceivedOf(data.SUBMIT_SM)) == 3
+		}, time.Second, 10*time.Millisecond)
+
+		require.Eventually(t, func() bool {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			return len(r.statuses) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, []data.CommandStatusType{data.ESME_ROK}, r.statuses)
+
+		submitted := smsc.ReceivedOf(data.SUBMIT_SM)
+		require.NotEqual(t, submitted[0].GetSequenceNumber(), submitted[2].GetSequenceNumber())
+		require.EqualValues(t, 2, atomic.LoadInt32(&cuts))
+		require.InDelta(t, 250, float64(session.bound().adaptive.current()), 1)
+	})
+
+	t.Run("max requeues", func(t *testing.T) {
+		smsc := newServer(10)
+		defer smsc.Close()
+
+		var r received
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout: time.Second,
+			OnPDU:       onPDU(&r),
+			AdaptiveRate: &AdaptiveRateSettings{
+				MaxRate:     1000,
+				Requeue:     true,
+				MaxRequeues: 1,
+			},
+		}, 0)
+		require.NoError(t, err)
+		defer func() {
+			_ = session.Close()
+		}()
+
+		require.NoError(t, session.Transceiver().Submit(newSubmitSM("esme")))
+		require.Eventually(t, func() bool {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			return len(r.statuses) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, []data.CommandStatusType{data.ESME_RTHROTTLED}, r.statuses)
+		require.Len(t, smsc.ReceivedOf(data.SUBMIT_SM), 2)
+
+		// one cut within default cooldown
+		require.Equal(t, rate.Limit(500), session.bound().adaptive.current())
+	})
+}
@@ -0,0 +1,148 @@
+package smpp
+
+import (
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+// DefaultWriteBatchPDUs is number of PDUs flushed at once if WriteBatchSettings.MaxPDUs is zero.
+const DefaultWriteBatchPDUs = 64
+
+// WriteBatchSettings coalesces PDUs queued for writing, so that each batch is marshaled
+// into one buffer and written by a single call to the connection.
+//
+// Each PDU of a failed batch is still checked on its own: PDUs written before the failure
+// count as sent, each of the others is reported to OnSubmitError. So is each PDU left queued
+// once transmitter is closing, with ErrConnectionClosing.
+type WriteBatchSettings struct {
+	// MaxPDUs flushes batch once it has this many PDUs. Zero defaults to DefaultWriteBatchPDUs.
+	MaxPDUs int
+
+	// MaxDelay is the longest time the first PDU of batch waits for more PDUs, e.g. one millisecond.
+	// Zero flushes as soon as nothing more is queued, so that batching adds no latency.
+	MaxDelay time.Duration
+}
+
+func (s WriteBatchSettings) maxPDUs() int {
+	if s.MaxPDUs <= 0 {
+		return DefaultWriteBatchPDUs
+	}
+	return s.MaxPDUs
+}
+
+// send queued PDU, along with PDUs queued after it if writes are batched.
+// Returns true if transmitter is closing.
+func (t *transmittable) send(q queuedPDU) (closing bool) {
+	if t.settings.WriteBatch == nil {
+		if q.p == nil || t.expired(q) {
+			return
+		}
+		n, err := t.write(q.p)
+		return t.check(q.p, n, err)
+	}
+
+	batch := t.collect(q)
+	closing = t.writeBatch(batch)
+
+	// do not hold written PDUs until the next batch
+	for i := range batch {
+		batch[i] = nil
+	}
+	t.batch = batch[:0]
+	return
+}
+
+// collect PDUs queued after q until batch is full, input is drained or MaxDelay passes.
+// Expired PDUs are left out.
+func (t *transmittable) collect(q queuedPDU) []pdu.PDU {
+	batch := t.batch[:0]
+	if q.p != nil && !t.expired(q) {
+		batch = append(batch, q.p)
+	}
+
+	settings := t.settings.WriteBatch
+	limit := settings.maxPDUs()
+
+	var deadline <-chan time.Time
+	for len(batch) < limit {
+		select {
+		case q, ok := <-t.input:
+			if !ok {
+				return batch
+			}
+			if q.p != nil && !t.expired(q) {
+				batch = append(batch, q.p)
+			}
+			continue
+
+		default:
+		}
+
+		if settings.MaxDelay <= 0 || len(batch) == 0 {
+			return batch
+		}
+
+		if deadline == nil {
+			timer := time.NewTimer(settings.MaxDelay)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+
+		select {
+		case q, ok := <-t.input:
+			if !ok {
+				return batch
+			}
+			if q.p != nil && !t.expired(q) {
+				batch = append(batch, q.p)
+			}
+
+		case <-deadline:
+			return batch
+		}
+	}
+
+	return batch
+}
+
+// writeBatch writes batch at once and checks each of its PDUs. Returns true if transmitter is closing.
+func (t *transmittable) writeBatch(batch []pdu.PDU) (closing bool) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var err error
+	if t.settings.WriteTimeout > 0 {
+		err = t.conn.SetWriteTimeout(t.settings.WriteTimeout)
+	}
+
+	// PDUs might be reused once written, e.g. requeued by adaptive rate
+	ids, seqs := t.ids[:0], t.seqs[:0]
+	for _, p := range batch {
+		ids = append(ids, p.GetHeader().CommandID)
+		seqs = append(seqs, p.GetSequenceNumber())
+	}
+	t.ids, t.seqs = ids, seqs
+
+	var sent, n int
+	if err == nil {
+		sent, n, err = t.conn.WritePDUs(batch)
+	}
+
+	for i, id := range ids[:sent] {
+		t.metrics.PDUSent(t.settings.sessionID, id)
+		if t.settings.written != nil {
+			t.settings.written(seqs[i])
+		}
+	}
+
+	// the first unsent PDU might be written partially, the others are not written at all
+	for _, p := range batch[sent:] {
+		if t.check(p, n, err) {
+			closing = true
+		}
+		n = 0
+	}
+	return
+}
@@ -0,0 +1,278 @@
+package smpp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingConn counts writes, failing them once limit bytes are written if limit is set.
+type countingConn struct {
+	net.Conn
+	writes  int32
+	limit   int
+	written int
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	atomic.AddInt32(&c.writes, 1)
+	if c.limit > 0 && c.written+len(b) > c.limit {
+		n := c.limit - c.written
+		c.written = c.limit
+		return n, fmt.Errorf("fake error")
+	}
+	c.written += len(b)
+	return c.Conn.Write(b)
+}
+
+func newBatchTransmittable(conn net.Conn, batch *WriteBatchSettings) *transmittable {
+	return newTransmittable(NewConnection(conn), Settings{WriteBatch: batch})
+}
+
+func TestWriteBatch(t *testing.T) {
+	t.Run("Coalescing", func(t *testing.T) {
+		client, server := net.Pipe()
+		conn := &countingConn{Conn: client}
+		m := NewMemoryMetrics(nil)
+
+		tr := newTransmittable(NewConnection(conn), Settings{
+			WriteBatch: &WriteBatchSettings{MaxPDUs: 4, MaxDelay: 50 * time.Millisecond},
+			Metrics:    m,
+			sessionID:  "s",
+		})
+		tr.start()
+		defer func() {
+			_ = tr.close(StoppingProcessOnly)
+			_ = client.Close()
+			_ = server.Close()
+		}()
+
+		received := make(chan pdu.PDU, 16)
+		go func() {
+			r := bufio.NewReader(server)
+			for {
+				p, err := pdu.Parse(r)
+				if err != nil {
+					return
+				}
+				received <- p
+			}
+		}()
+
+		for i := 0; i < 10; i++ {
+			p := pdu.NewEnquireLink()
+			p.SetSequenceNumber(int32(i + 1))
+			require.NoError(t, tr.Submit(p))
+		}
+
+		for i := 0; i < 10; i++ {
+			select {
+			case p := <-received:
+				require.Equal(t, int32(i+1), p.GetSequenceNumber())
+			case <-time.After(time.Second):
+				t.Fatal("PDU is not written")
+			}
+		}
+
+		// 10 PDUs flushed by 4 at most
+		writes := atomic.LoadInt32(&conn.writes)
+		require.GreaterOrEqual(t, writes, int32(3))
+		require.Less(t, writes, int32(10))
+
+		// pipe delivers PDUs before write returns
+		require.Eventually(t, func() bool {
+			return m.Snapshot().Sent[MetricKey{Session: "s", CommandID: data.ENQUIRE_LINK}] == 10
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("MaxDelay", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer func() {
+			_ = client.Close()
+			_ = server.Close()
+		}()
+
+		tr := newBatchTransmittable(client, &WriteBatchSettings{MaxPDUs: 100, MaxDelay: 30 * time.Millisecond})
+		tr.skipUnbind()
+		tr.start()
+		defer func() {
+			_ = tr.close(StoppingProcessOnly)
+		}()
+
+		start := time.Now()
+		require.NoError(t, tr.Submit(pdu.NewEnquireLink()))
+
+		// single PDU waits for more until MaxDelay
+		_, err := pdu.Parse(bufio.NewReader(server))
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer func() {
+			_ = server.Close()
+		}()
+		go func() {
+			buf := make([]byte, 1024)
+			for {
+				if _, err := server.Read(buf); err != nil {
+					return
+				}
+			}
+		}()
+
+		// the first PDU fits, the second is written partially
+		conn := &countingConn{Conn: client, limit: 20}
+		m := NewMemoryMetrics(nil)
+
+		var mu sync.Mutex
+		var failed []int32
+		closed := make(chan State, 1)
+		tr := newTransmittable(NewConnection(conn), Settings{
+			WriteBatch: &WriteBatchSettings{MaxPDUs: 3},
+			Metrics:    m,
+			sessionID:  "s",
+			OnSubmitError: func(p pdu.PDU, err error) {
+				require.Error(t, err)
+				mu.Lock()
+				failed = append(failed, p.GetSequenceNumber())
+				mu.Unlock()
+			},
+			OnClosed: func(state State) {
+				closed <- state
+			},
+		})
+		tr.skipUnbind()
+
+		batch := make([]pdu.PDU, 0, 3)
+		for i := 0; i < 3; i++ {
+			p := pdu.NewEnquireLink()
+			p.SetSequenceNumber(int32(i + 1))
+			batch = append(batch, p)
+		}
+		tr.wg.Add(1)
+		go func() {
+			defer tr.wg.Done()
+			require.True(t, tr.writeBatch(batch))
+		}()
+
+		select {
+		case state := <-closed:
+			require.Equal(t, ConnectionIssue, state)
+		case <-time.After(time.Second):
+			t.Fatal("transmitter is not closed")
+		}
+
+		mu.Lock()
+		require.Equal(t, []int32{2, 3}, failed)
+		mu.Unlock()
+		require.Equal(t, uint64(1), m.Snapshot().Sent[MetricKey{Session: "s", CommandID: data.ENQUIRE_LINK}])
+	})
+
+	t.Run("Drain", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer func() {
+			_ = client.Close()
+			_ = server.Close()
+		}()
+
+		var reported int32
+		tr := newTransmittable(NewConnection(&countingConn{Conn: client, limit: 1}), Settings{
+			WriteBatch: &WriteBatchSettings{MaxPDUs: 2},
+			OnSubmitError: func(_ pdu.PDU, err error) {
+				require.Error(t, err)
+				atomic.AddInt32(&reported, 1)
+			},
+		})
+		tr.skipUnbind()
+		tr.start()
+
+		// each PDU is either rejected or reported, none is discarded silently
+		var rejected int32
+		for i := 0; i < 10; i++ {
+			if err := tr.Submit(pdu.NewEnquireLink()); err != nil {
+				require.ErrorIs(t, err, ErrConnectionClosing)
+				rejected++
+			}
+		}
+		_ = tr.close(StoppingProcessOnly)
+
+		require.Equal(t, int32(10), rejected+atomic.LoadInt32(&reported))
+	})
+
+	t.Run("Session", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout: time.Second,
+			WriteBatch:  &WriteBatchSettings{MaxDelay: time.Millisecond},
+			WindowSize:  50,
+		}, -1)
+		require.NoError(t, err)
+		defer func() {
+			_ = session.Close()
+		}()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := session.Transceiver().SubmitResp(context.Background(), newSubmitSM("esme"))
+				require.NoError(t, err)
+				require.Equal(t, data.ESME_ROK, resp.GetHeader().CommandStatus)
+			}()
+		}
+		wg.Wait()
+		require.Len(t, smsc.ReceivedOf(data.SUBMIT_SM), 50)
+	})
+}
+
+func BenchmarkWriteBatch(b *testing.B) {
+	benchmark := func(b *testing.B, batch *WriteBatchSettings) {
+		client, server := net.Pipe()
+		go func() {
+			buf := make([]byte, 64<<10)
+			for {
+				if _, err := server.Read(buf); err != nil {
+					return
+				}
+			}
+		}()
+
+		tr := newBatchTransmittable(client, batch)
+		tr.start()
+
+		p := newSubmitSM("esme")
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = tr.Submit(p)
+		}
+		b.StopTimer()
+
+		tr.skipUnbind()
+		_ = tr.close(ExplicitClosing)
+		_ = server.Close()
+	}
+
+	b.Run("single", func(b *testing.B) {
+		benchmark(b, nil)
+	})
+	b.Run("batched", func(b *testing.B) {
+		benchmark(b, &WriteBatchSettings{})
+	})
+}
@@ -0,0 +1,284 @@
+package smpp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+// CaptureIndexSuffix is appended to capture file path to name its JSON index.
+const CaptureIndexSuffix = ".json"
+
+// CaptureDirection tells whether recorded PDU was read from or written to the connection.
+type CaptureDirection string
+
+const (
+	CaptureRead    CaptureDirection = "read"
+	CaptureWritten CaptureDirection = "written"
+)
+
+// CaptureRecord is entry of capture index, describing one PDU stored in capture data.
+type CaptureRecord struct {
+	Time      time.Time        `json:"time"`
+	Conn      uint64           `json:"conn"`
+	Direction CaptureDirection `json:"direction"`
+
+	// Offset and Length locate raw PDU within capture data.
+	Offset int64 `json:"offset"`
+	Length int   `json:"length"`
+
+	CommandID      string `json:"command_id"`
+	CommandStatus  string `json:"command_status"`
+	SequenceNumber int32  `json:"sequence_number"`
+
+	// Malformed marks bytes which do not frame a valid PDU, recorded until end of the connection.
+	Malformed bool `json:"malformed,omitempty"`
+}
+
+// Recorder records PDUs passing through wrapped connections into capture:
+// raw PDUs are appended to data, each described by a JSON line of index.
+//
+// Capture failures do not affect traffic, the first one is reported by Close and Err.
+type Recorder struct {
+	conns uint64
+
+	mu     sync.Mutex
+	data   io.Writer
+	index  *json.Encoder
+	offset int64
+	err    error
+	closer []io.Closer
+}
+
+// NewRecorder creates Recorder writing raw PDUs to data and JSON index to index.
+func NewRecorder(data, index io.Writer) *Recorder {
+	return &Recorder{data: data, index: json.NewEncoder(index)}
+}
+
+// CreateCapture creates Recorder writing capture data to path and its index to path+CaptureIndexSuffix.
+func CreateCapture(path string) (*Recorder, error) {
+	dataFile, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	indexFile, err := os.Create(path + CaptureIndexSuffix)
+	if err != nil {
+		_ = dataFile.Close()
+		return nil, err
+	}
+
+	r := NewRecorder(dataFile, indexFile)
+	r.closer = []io.Closer{dataFile, indexFile}
+	return r, nil
+}
+
+// Dialer wraps dialer so that its connections are recorded.
+func (r *Recorder) Dialer(dialer Dialer) Dialer {
+	return func(addr string) (net.Conn, error) {
+		conn, err := dialer(addr)
+		if err != nil {
+			return nil, err
+		}
+		return r.Wrap(conn), nil
+	}
+}
+
+// Wrap connection so that PDUs read from and written to it are recorded.
+func (r *Recorder) Wrap(conn net.Conn) net.Conn {
+	id := atomic.AddUint64(&r.conns, 1)
+	return &recordingConn{
+		Conn:    conn,
+		read:    &captureFramer{recorder: r, conn: id, direction: CaptureRead},
+		written: &captureFramer{recorder: r, conn: id, direction: CaptureWritten},
+	}
+}
+
+// Err returns the first capture failure.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close files created by CreateCapture. Returns the first capture failure if any.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.closer {
+		if err := c.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	r.closer = nil
+	return r.err
+}
+
+func (r *Recorder) record(conn uint64, direction CaptureDirection, raw []byte, malformed bool) {
+	rec := CaptureRecord{
+		Time:      time.Now(),
+		Conn:      conn,
+		Direction: direction,
+		Length:    len(raw),
+		Malformed: malformed,
+	}
+	if !malformed {
+		var header [16]byte
+		copy(header[:], raw)
+		h := pdu.ParseHeader(header)
+		rec.CommandID = h.CommandID.String()
+		rec.CommandStatus = h.CommandStatus.String()
+		rec.SequenceNumber = h.SequenceNumber
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	rec.Offset = r.offset
+	if _, r.err = r.data.Write(raw); r.err == nil {
+		r.offset += int64(len(raw))
+		r.err = r.index.Encode(rec)
+	}
+}
+
+// recordingConn frames bytes passing through connection into PDUs and records them.
+type recordingConn struct {
+	net.Conn
+	read    *captureFramer
+	written *captureFramer
+}
+
+func (c *recordingConn) Read(b []byte) (n int, err error) {
+	n, err = c.Conn.Read(b)
+	c.read.feed(b[:n])
+	return
+}
+
+func (c *recordingConn) Write(b []byte) (n int, err error) {
+	n, err = c.Conn.Write(b)
+	c.written.feed(b[:n])
+	return
+}
+
+// captureFramer splits stream of one direction into PDUs by command_length.
+type captureFramer struct {
+	recorder  *Recorder
+	conn      uint64
+	direction CaptureDirection
+
+	mu        sync.Mutex
+	buf       []byte
+	malformed bool
+}
+
+func (f *captureFramer) feed(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.malformed {
+		f.recorder.record(f.conn, f.direction, b, true)
+		return
+	}
+
+	f.buf = append(f.buf, b...)
+	for len(f.buf) >= 4 {
+		length := binary.BigEndian.Uint32(f.buf)
+		if length < 16 || length > data.MAX_PDU_LEN {
+			// stream can not be framed anymore
+			f.malformed = true
+			f.recorder.record(f.conn, f.direction, f.buf, true)
+			f.buf = nil
+			return
+		}
+
+		if len(f.buf) < int(length) {
+			return
+		}
+
+		f.recorder.record(f.conn, f.direction, f.buf[:length], false)
+		f.buf = append(f.buf[:0], f.buf[length:]...)
+	}
+}
+
+// Capture is recorded traffic, see Recorder.
+type Capture struct {
+	Records []CaptureRecord
+	data    []byte
+}
+
+// OpenCapture reads capture created by CreateCapture.
+func OpenCapture(path string) (*Capture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := os.Open(path + CaptureIndexSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = index.Close()
+	}()
+
+	return ReadCapture(data, index)
+}
+
+// ReadCapture reads capture from its data and JSON index.
+func ReadCapture(data []byte, index io.Reader) (*Capture, error) {
+	c := &Capture{data: data}
+
+	scanner := bufio.NewScanner(index)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var rec CaptureRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("capture index line %d: %w", line, err)
+		}
+		if rec.Offset < 0 || rec.Length < 0 || rec.Offset+int64(rec.Length) > int64(len(data)) {
+			return nil, fmt.Errorf("capture index line %d: record is out of capture data", line)
+		}
+		c.Records = append(c.Records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Raw returns raw bytes of i-th record.
+func (c *Capture) Raw(i int) []byte {
+	rec := c.Records[i]
+	return c.data[rec.Offset : rec.Offset+int64(rec.Length)]
+}
+
+// PDU parses i-th record.
+func (c *Capture) PDU(i int) (pdu.PDU, error) {
+	if c.Records[i].Malformed {
+		return nil, fmt.Errorf("capture record %d is malformed", i)
+	}
+	return pdu.Parse(bytes.NewReader(c.Raw(i)))
+}
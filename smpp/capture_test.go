@@ -0,0 +1,130 @@
+package smpp
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordSession records traffic of session binding to smsc and submitting one submit_sm.
+func recordSession(t *testing.T, smsc *smsctest.Server, recorder *Recorder) {
+	session, err := NewSession(TRXConnector(recorder.Dialer(smsc.Dial), Auth{SMSC: smsc.Addr(), SystemID: "esme"}), Settings{
+		ReadTimeout: time.Second,
+	}, 0)
+	require.NoError(t, err)
+
+	_, err = session.Transceiver().SubmitResp(context.Background(), newSubmitSM("esme"))
+	require.NoError(t, err)
+
+	_, err = session.Shutdown(context.Background())
+	require.NoError(t, err)
+}
+
+// findRecord returns index of the first record of command_id.
+func findRecord(c *Capture, commandID data.CommandIDType) int {
+	for i, rec := range c.Records {
+		if rec.CommandID == commandID.String() {
+			return i
+		}
+	}
+	return -1
+}
+
+func commandIDs(c *Capture, direction CaptureDirection) (ids []string) {
+	for _, rec := range c.Records {
+		if rec.Direction == direction {
+			ids = append(ids, rec.CommandID)
+		}
+	}
+	return
+}
+
+func TestRecorder(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	path := filepath.Join(t.TempDir(), "capture.bin")
+	recorder, err := CreateCapture(path)
+	require.NoError(t, err)
+
+	recordSession(t, smsc, recorder)
+	require.NoError(t, recorder.Close())
+
+	capture, err := OpenCapture(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"BIND_TRANSCEIVER", "SUBMIT_SM", "UNBIND"}, commandIDs(capture, CaptureWritten))
+	require.Equal(t, []string{"BIND_TRANSCEIVER_RESP", "SUBMIT_SM_RESP", "UNBIND_RESP"}, commandIDs(capture, CaptureRead))
+
+	for i, rec := range capture.Records {
+		require.EqualValues(t, 1, rec.Conn)
+		require.False(t, rec.Time.IsZero())
+
+		p, err := capture.PDU(i)
+		require.NoError(t, err)
+		require.Equal(t, rec.CommandID, p.GetHeader().CommandID.String())
+		require.Equal(t, rec.SequenceNumber, p.GetSequenceNumber())
+	}
+
+	p, err := capture.PDU(findRecord(capture, data.SUBMIT_SM))
+	require.NoError(t, err)
+	require.Equal(t, "esme", p.(*pdu.SubmitSM).SourceAddr.Address())
+}
+
+func TestCaptureFramer(t *testing.T) {
+	var dataBuf, index bytes.Buffer
+	recorder := NewRecorder(&dataBuf, &index)
+	f := &captureFramer{recorder: recorder, conn: 1, direction: CaptureRead}
+
+	buf := pdu.NewBuffer(nil)
+	enquireLink := pdu.NewEnquireLink()
+	enquireLink.SetSequenceNumber(7)
+	enquireLink.Marshal(buf)
+	raw := buf.Bytes()
+
+	// split across reads, then two PDUs in one read
+	f.feed(raw[:3])
+	f.feed(raw[3:10])
+	f.feed(append(append(raw[10:], raw...), raw[:5]...))
+	f.feed(raw[5:])
+
+	// garbage turns rest of the stream malformed
+	f.feed([]byte{0, 0, 0, 1, 2})
+	f.feed(raw)
+
+	capture, err := ReadCapture(dataBuf.Bytes(), &index)
+	require.NoError(t, err)
+	require.Len(t, capture.Records, 5)
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, "ENQUIRE_LINK", capture.Records[i].CommandID)
+		require.EqualValues(t, 7, capture.Records[i].SequenceNumber)
+		require.Equal(t, raw, capture.Raw(i))
+	}
+
+	require.True(t, capture.Records[3].Malformed)
+	require.Equal(t, []byte{0, 0, 0, 1, 2}, capture.Raw(3))
+	require.True(t, capture.Records[4].Malformed)
+	_, err = capture.PDU(4)
+	require.Error(t, err)
+	require.NoError(t, recorder.Err())
+}
+
+func TestReadCapture(t *testing.T) {
+	_, err := ReadCapture(nil, bytes.NewBufferString(`{"offset":0,"length":16}`))
+	require.Error(t, err)
+
+	_, err = ReadCapture(nil, bytes.NewBufferString("{"))
+	require.Error(t, err)
+
+	c, err := ReadCapture(nil, bytes.NewBufferString("\n"))
+	require.NoError(t, err)
+	require.Empty(t, c.Records)
+}
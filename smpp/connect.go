@@ -1,8 +1,10 @@
 package smpp
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/sujit-baniya/protocol/smpp/data"
 	"github.com/sujit-baniya/protocol/smpp/pdu"
@@ -15,6 +17,15 @@ var (
 	}
 )
 
+// BindError indicates SMSC rejected bind request with non-zero command status.
+type BindError struct {
+	Status data.CommandStatusType
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("binding error. Command status: [%d]. Please refer to: https://github.com/sujit-baniya/protocol/smpp/blob/master/data/pkg.go for more detail about this status code", e.Status)
+}
+
 // Dialer is connection dialer.
 type Dialer func(addr string) (net.Conn, error)
 
@@ -25,6 +36,9 @@ type Auth struct {
 	SystemID   string
 	Password   string
 	SystemType string
+
+	// InterfaceVersion requested within bind, e.g. data.SMPP_V50. Zero requests data.SMPP_V34.
+	InterfaceVersion byte
 }
 
 func newBindRequest(s Auth, bindingType pdu.BindingType) (bindReq *pdu.BindRequest) {
@@ -32,12 +46,19 @@ func newBindRequest(s Auth, bindingType pdu.BindingType) (bindReq *pdu.BindReque
 	bindReq.SystemID = s.SystemID
 	bindReq.Password = s.Password
 	bindReq.SystemType = s.SystemType
+	if s.InterfaceVersion != 0 {
+		bindReq.InterfaceVersion = s.InterfaceVersion
+	}
 	return
 }
 
 // Connector is connection factory interface.
 type Connector interface {
 	Connect() (conn *Connection, err error)
+
+	// ConnectContext is like Connect, but dialing and binding are aborted
+	// once ctx is done.
+	ConnectContext(ctx context.Context) (conn *Connection, err error)
 }
 
 type connector struct {
@@ -47,20 +68,103 @@ type connector struct {
 }
 
 func (c *connector) Connect() (conn *Connection, err error) {
-	conn, err = connect(c.dialer, c.auth.SMSC, newBindRequest(c.auth, c.bindingType))
+	return c.ConnectContext(context.Background())
+}
+
+func (c *connector) ConnectContext(ctx context.Context) (conn *Connection, err error) {
+	conn, err = connect(ctx, c.dialer, c.auth.SMSC, newBindRequest(c.auth, c.bindingType))
 	return
 }
 
-func connect(dialer Dialer, addr string, bindReq *pdu.BindRequest) (c *Connection, err error) {
-	conn, err := dialer(addr)
+func connect(ctx context.Context, dialer Dialer, addr string, bindReq *pdu.BindRequest) (c *Connection, err error) {
+	conn, err := dial(ctx, dialer, addr)
 	if err != nil {
 		return
 	}
 
+	return bind(ctx, conn, bindReq)
+}
+
+// dial runs dialer, which is abandoned once ctx is done.
+// Connection established after abandoning is closed.
+func dial(ctx context.Context, dialer Dialer, addr string) (net.Conn, error) {
+	if ctx.Done() == nil {
+		return dialer(addr)
+	}
+
+	type dialed struct {
+		conn net.Conn
+		err  error
+	}
+
+	result := make(chan dialed, 1)
+	go func() {
+		conn, err := dialer(addr)
+		result <- dialed{conn: conn, err: err}
+	}()
+
+	select {
+	case d := <-result:
+		return d.conn, d.err
+
+	case <-ctx.Done():
+		go func() {
+			if d := <-result; d.conn != nil {
+				_ = d.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// bind sends binding request over established conn and waits for bind_resp.
+// The conn is closed if binding fails.
+//
+// Deadline of ctx applies to conn while binding, and ctx being done aborts blocking I/O.
+func bind(ctx context.Context, conn net.Conn, bindReq *pdu.BindRequest) (c *Connection, err error) {
+	callBindingHook(ctx)
+
+	if ctx.Done() != nil {
+		if deadline, ok := ctx.Deadline(); ok {
+			if err = conn.SetDeadline(deadline); err != nil {
+				_ = conn.Close()
+				return
+			}
+		}
+
+		stop, stopped := make(chan struct{}), make(chan struct{})
+		go func() {
+			defer close(stopped)
+			select {
+			case <-ctx.Done():
+				// unblock pending read/write
+				_ = conn.SetDeadline(time.Unix(1, 0))
+			case <-stop:
+			}
+		}()
+
+		defer func() {
+			close(stop)
+			<-stopped
+
+			if err != nil {
+				if ctx.Err() != nil {
+					err = ctx.Err()
+				}
+				return
+			}
+
+			if err = conn.SetDeadline(time.Time{}); err != nil {
+				_ = conn.Close()
+			}
+		}()
+	}
+
 	// create wrapped connection
 	c = NewConnection(conn)
 
 	// send binding request
+	c.AssignSequenceNumber(bindReq)
 	_, err = c.WritePDU(bindReq)
 	if err != nil {
 		_ = conn.Close()
@@ -86,10 +190,11 @@ func connect(dialer Dialer, addr string, bindReq *pdu.BindRequest) (c *Connectio
 	}
 
 	if resp.CommandStatus != data.ESME_ROK {
-		err = fmt.Errorf("binding error. Command status: [%d]. Please refer to: https://github.com/sujit-baniya/protocol/smpp/blob/master/data/pkg.go for more detail about this status code", resp.CommandStatus)
+		err = &BindError{Status: resp.CommandStatus}
 		_ = conn.Close()
 	} else {
 		c.systemID = resp.SystemID
+		c.interfaceVersion = negotiateInterfaceVersion(bindReq.InterfaceVersion, resp)
 	}
 
 	return
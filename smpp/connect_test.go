@@ -1,8 +1,17 @@
 package smpp
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"os"
 	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -14,10 +23,22 @@ var auths = [][2]string{
 }
 
 const (
-	smscAddr = "smscsim.melroselabs.com:2775"
-	mess     = "Thử nghiệm: chuẩn bị nế mễ"
+	mess = "Thử nghiệm: chuẩn bị nế mễ"
 )
 
+// smscAddr is address of in-process SMSC simulator, started by TestMain.
+var smscAddr string
+
+func TestMain(m *testing.M) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	smscAddr = smsc.Addr()
+
+	code := m.Run()
+
+	smsc.Close()
+	os.Exit(code)
+}
+
 func nextAuth() Auth {
 	pair := int(atomic.AddInt32(&currentAuth, 1)) % len(auths)
 	return Auth{
@@ -48,3 +69,52 @@ func TestBindingSMSC(t *testing.T) {
 		checker(t, TRXConnector(NonTLSDialer, nextAuth()))
 	})
 }
+
+func TestConnectContext(t *testing.T) {
+	t.Run("dialing", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		blocking := func(addr string) (net.Conn, error) {
+			<-release
+			return nil, fmt.Errorf("released")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := TRXConnector(blocking, nextAuth()).ConnectContext(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("binding", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+		smsc.Stall()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := TRXConnector(NonTLSDialer, Auth{SMSC: smsc.Addr()}).ConnectContext(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Less(t, time.Since(start), time.Second)
+		require.Len(t, smsc.ReceivedOf(data.BIND_TRANSCEIVER), 1)
+
+		smsc.Resume()
+
+		// deadline does not outlive binding
+		ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		conn, err := TRXConnector(NonTLSDialer, Auth{SMSC: smsc.Addr()}).ConnectContext(ctx)
+		require.NoError(t, err)
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		<-ctx.Done()
+		_, err = conn.WritePDU(pdu.NewEnquireLink())
+		require.NoError(t, err)
+	})
+}
@@ -1,6 +1,7 @@
 package smpp
 
 import (
+	"bufio"
 	"net"
 	"sync"
 	"time"
@@ -8,30 +9,74 @@ import (
 	"github.com/sujit-baniya/protocol/smpp/pdu"
 )
 
+// DefaultReadBufferSize is size of Connection read buffer. PDUs fitting within it are parsed without copying.
+const DefaultReadBufferSize = 4096
+
 // Connection wraps over net.Conn with buffered data reader.
 type Connection struct {
-	systemID string
-	conn     net.Conn
-	mutex    *sync.Mutex
+	systemID         string
+	interfaceVersion byte
+	conn             net.Conn
+	reader           *bufio.Reader
+	mutex            *sync.Mutex
+	sequence         SequenceGenerator
 }
 
 // NewConnection returns a Connection.
+//
+// Connection numbers its requests with own SequenceCounter, starting from MinSequenceNumber.
 func NewConnection(conn net.Conn) (c *Connection) {
 	c = &Connection{
-		conn:  conn,
-		mutex: &sync.Mutex{},
+		conn:     conn,
+		reader:   bufio.NewReaderSize(conn, DefaultReadBufferSize),
+		mutex:    &sync.Mutex{},
+		sequence: NewSequenceCounter(0),
 	}
 	return
 }
 
+// SetSequenceGenerator replaces generator of request sequence numbers.
+func (c *Connection) SetSequenceGenerator(g SequenceGenerator) {
+	c.sequence = g
+}
+
+// SequenceGenerator returns generator of request sequence numbers.
+func (c *Connection) SequenceGenerator() SequenceGenerator {
+	return c.sequence
+}
+
+// InterfaceVersion returns interface version negotiated with SMSC while binding.
+func (c *Connection) InterfaceVersion() byte {
+	return c.interfaceVersion
+}
+
+// AssignSequenceNumber assigns next sequence number to request PDU.
+// Responses keep sequence number of their requests.
+func (c *Connection) AssignSequenceNumber(p pdu.PDU) {
+	if isRequest(p) {
+		p.SetSequenceNumber(c.sequence.Next())
+	}
+}
+
 // Read reads data from the connection.
 // Read can be made to time out and return an Error with Timeout() == true
 // after a fixed time limit; see SetDeadline and SetReadDeadline.
 func (c *Connection) Read(b []byte) (n int, err error) {
-	n, err = c.conn.Read(b)
+	n, err = c.reader.Read(b)
 	return
 }
 
+// Peek returns the next n buffered bytes without advancing the reader, see bufio.Reader.
+// Together with Discard, it lets pdu.Parse unmarshal PDUs without copying.
+func (c *Connection) Peek(n int) ([]byte, error) {
+	return c.reader.Peek(n)
+}
+
+// Discard skips the next n buffered bytes, see bufio.Reader.
+func (c *Connection) Discard(n int) (int, error) {
+	return c.reader.Discard(n)
+}
+
 // Write writes data to the connection.
 // Write can be made to time out and return an Error with Timeout() == true
 // after a fixed time limit; see SetDeadline and SetWriteDeadline.
@@ -44,12 +89,42 @@ func (c *Connection) Write(b []byte) (n int, err error) {
 func (c *Connection) WritePDU(p pdu.PDU) (n int, err error) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	buf := pdu.NewBuffer(make([]byte, 0, 64))
+	buf := pdu.AcquireBuffer()
+	defer pdu.ReleaseBuffer(buf)
+
 	p.Marshal(buf)
 	n, err = c.conn.Write(buf.Bytes())
 	return
 }
 
+// WritePDUs marshals PDUs into one buffer and writes it at once.
+// On failure, sent is number of PDUs written completely and n is number of bytes written of the next one.
+func (c *Connection) WritePDUs(ps []pdu.PDU) (sent, n int, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	buf := pdu.AcquireBuffer()
+	defer pdu.ReleaseBuffer(buf)
+
+	ends := make([]int, len(ps))
+	for i, p := range ps {
+		p.Marshal(buf)
+		ends[i] = buf.Len()
+	}
+
+	written, err := c.conn.Write(buf.Bytes())
+	if err == nil {
+		return len(ps), 0, nil
+	}
+
+	start := 0
+	for sent < len(ps) && ends[sent] <= written {
+		start = ends[sent]
+		sent++
+	}
+	n = written - start
+	return
+}
+
 // Close closes the connection.
 // Any blocked Read or Write operations will be unblocked and return errors.
 func (c *Connection) Close() error {
@@ -5,11 +5,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+
 	"github.com/stretchr/testify/require"
 )
 
 func TestConnection(t *testing.T) {
-	conn, err := net.Dial("tcp", "smscsim.melroselabs.com:2775")
+	conn, err := net.Dial("tcp", smscAddr)
 	require.Nil(t, err)
 
 	c := NewConnection(conn)
@@ -23,3 +26,80 @@ func TestConnection(t *testing.T) {
 	require.Nil(t, c.SetWriteDeadline(time.Now().Add(5*time.Second)))
 	require.Nil(t, c.SetReadDeadline(time.Now().Add(5*time.Second)))
 }
+
+func newDeliverSM() *pdu.DeliverSM {
+	p := pdu.NewDeliverSM().(*pdu.DeliverSM)
+	_ = p.SourceAddr.SetAddress("12345")
+	_ = p.DestAddr.SetAddress("esme")
+	_ = p.Message.SetMessageWithEncoding(mess, coding.UCS2)
+	return p
+}
+
+// newRoundTrip returns function writing request and reading its response over
+// connection whose peer responds to each request.
+func newRoundTrip(tb testing.TB) func(req pdu.PDU) {
+	client, server := net.Pipe()
+	c, s := NewConnection(client), NewConnection(server)
+	tb.Cleanup(func() {
+		_ = c.Close()
+		_ = s.Close()
+	})
+
+	go func() {
+		for {
+			p, err := pdu.Parse(s)
+			if err != nil {
+				return
+			}
+			if _, err = s.WritePDU(p.GetResponse()); err != nil {
+				return
+			}
+		}
+	}()
+
+	return func(req pdu.PDU) {
+		if _, err := c.WritePDU(req); err != nil {
+			tb.Fatal(err)
+		}
+		if _, err := pdu.Parse(c); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func benchmarkRoundTrip(b *testing.B, req pdu.PDU) {
+	roundTrip := newRoundTrip(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		roundTrip(req)
+	}
+}
+
+func BenchmarkSubmitSMRoundTrip(b *testing.B) {
+	benchmarkRoundTrip(b, newSubmitSM("esme"))
+}
+
+func BenchmarkDeliverSMRoundTrip(b *testing.B) {
+	benchmarkRoundTrip(b, newDeliverSM())
+}
+
+// TestRoundTripAllocs keeps allocations of writing and parsing on both sides from regressing.
+// They come from PDUs and their fields only, buffers are pooled or peeked.
+func TestRoundTripAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("race detector allocates")
+	}
+
+	for name, req := range map[string]pdu.PDU{
+		"submit_sm":  newSubmitSM("esme"),
+		"deliver_sm": newDeliverSM(),
+	} {
+		roundTrip := newRoundTrip(t)
+		allocs := testing.AllocsPerRun(100, func() {
+			roundTrip(req)
+		})
+		require.LessOrEqual(t, allocs, float64(10), name)
+	}
+}
@@ -38,6 +38,14 @@ const (
 	ALERT_NOTIFICATION    = CommandIDType(0x00000102)
 	DATA_SM               = CommandIDType(0x00000103)
 	DATA_SM_RESP          = CommandIDType(-2147483389)
+
+	// SMPP v5.0 Command ID Set
+	BROADCAST_SM             = CommandIDType(0x00000111)
+	BROADCAST_SM_RESP        = CommandIDType(-2147483375)
+	QUERY_BROADCAST_SM       = CommandIDType(0x00000112)
+	QUERY_BROADCAST_SM_RESP  = CommandIDType(-2147483374)
+	CANCEL_BROADCAST_SM      = CommandIDType(0x00000113)
+	CANCEL_BROADCAST_SM_RESP = CommandIDType(-2147483373)
 )
 
 //nolint
@@ -153,5 +161,26 @@ const (
 	ESME_RDELIVERYFAILURE = CommandStatusType(0x000000FE)
 	ESME_RUNKNOWNERR      = CommandStatusType(0x000000FF)
 
+	// SMPP v5.0 Command_Status Error Codes
+	ESME_RSERTYPUNAUTH       = CommandStatusType(0x000000C5) // ESME Not authorised to use specified service_type
+	ESME_RPROHIBITED         = CommandStatusType(0x000000C6) // ESME Prohibited from using specified operation
+	ESME_RSERTYPUNAVAIL      = CommandStatusType(0x000000C7) // Specified service_type is unavailable
+	ESME_RSERTYPDENIED       = CommandStatusType(0x000000C8) // Specified service_type is denied
+	ESME_RINVDCS             = CommandStatusType(0x000000C9) // Invalid Data Coding Scheme
+	ESME_RINVSRCADDRSUBUNIT  = CommandStatusType(0x000000CA) // Source Address Sub unit is Invalid
+	ESME_RINVDSTADDRSUBUNIT  = CommandStatusType(0x000000CB) // Destination Address Sub unit is Invalid
+	ESME_RINVBCASTFREQINT    = CommandStatusType(0x00000100) // Broadcast Frequency Interval is invalid
+	ESME_RINVBCASTALIAS_NAME = CommandStatusType(0x00000101) // Broadcast Alias Name is invalid
+	ESME_RINVBCASTAREAFMT    = CommandStatusType(0x00000102) // Broadcast Area Format is invalid
+	ESME_RINVNUMBCAST_AREAS  = CommandStatusType(0x00000103) // Number of Broadcast Areas is invalid
+	ESME_RINVBCASTCNTTYPE    = CommandStatusType(0x00000104) // Broadcast Content Type is invalid
+	ESME_RINVBCASTMSGCLASS   = CommandStatusType(0x00000105) // Broadcast Message Class is invalid
+	ESME_RBCASTFAIL          = CommandStatusType(0x00000106) // broadcast_sm operation failed
+	ESME_RBCASTQUERYFAIL     = CommandStatusType(0x00000107) // query_broadcast_sm operation failed
+	ESME_RBCASTCANCELFAIL    = CommandStatusType(0x00000108) // cancel_broadcast_sm operation failed
+	ESME_RINVBCAST_REP       = CommandStatusType(0x00000109) // Number of Repeated Broadcasts is invalid
+	ESME_RINVBCASTSRVGRP     = CommandStatusType(0x0000010A) // Broadcast Service Group is invalid
+	ESME_RINVBCASTCHANIND    = CommandStatusType(0x0000010B) // Broadcast Channel Indicator is invalid
+
 	ESME_LAST_ERROR = CommandStatusType(0x0000012C) // THE VALUE OF THE LAST ERROR CODE
 )
@@ -38,6 +38,28 @@ const (
 	// Interface_Version
 	SMPP_V33 int8 = int8(-0x33)
 	SMPP_V34      = byte(0x34)
+	SMPP_V50      = byte(0x50)
+
+	// Broadcast_Area_Identifier format
+	BROADCAST_AREA_FORMAT_ALIAS         = byte(0x00)
+	BROADCAST_AREA_FORMAT_ELLIPSOID_ARC = byte(0x01)
+	BROADCAST_AREA_FORMAT_POLYGON       = byte(0x02)
+
+	// Broadcast_Content_Type network type
+	BROADCAST_NETWORK_GENERIC = byte(0x00)
+	BROADCAST_NETWORK_GSM     = byte(0x01)
+	BROADCAST_NETWORK_TDMA    = byte(0x02)
+	BROADCAST_NETWORK_CDMA    = byte(0x03)
+
+	// Broadcast_Frequency_Interval time unit
+	BROADCAST_FREQ_AS_FREQUENTLY_AS_POSSIBLE = byte(0x00)
+	BROADCAST_FREQ_SECONDS                   = byte(0x08)
+	BROADCAST_FREQ_MINUTES                   = byte(0x09)
+	BROADCAST_FREQ_HOURS                     = byte(0x0A)
+	BROADCAST_FREQ_DAYS                      = byte(0x0B)
+	BROADCAST_FREQ_WEEKS                     = byte(0x0C)
+	BROADCAST_FREQ_MONTHS                    = byte(0x0D)
+	BROADCAST_FREQ_YEARS                     = byte(0x0E)
 
 	// Address_TON
 	GSM_TON_UNKNOWN       = byte(0x00)
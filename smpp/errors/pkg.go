@@ -32,4 +32,19 @@ var (
 
 	// ErrUDHTooLong UDH-L is larger than total length of short message data
 	ErrUDHTooLong = fmt.Errorf("User Data Header is too long for PDU short message")
+
+	// ErrNotDeliveryReceipt indicates esm_class of PDU does not mark a delivery receipt.
+	ErrNotDeliveryReceipt = fmt.Errorf("PDU is not a delivery receipt")
+
+	// ErrInvalidDeliveryReceipt indicates delivery receipt carries no message id.
+	ErrInvalidDeliveryReceipt = fmt.Errorf("Delivery receipt has no message id")
+
+	// ErrTooManySegments indicates message needs more segments than concatenation info can address.
+	ErrTooManySegments = fmt.Errorf("Message exceeds maximum number of %d segments", 255)
+
+	// ErrUnknownSegmentation indicates unsupported segmentation method.
+	ErrUnknownSegmentation = fmt.Errorf("Unknown segmentation method")
+
+	// ErrMessagePayloadTooLarge indicates message_payload exceeds maximum TLV length.
+	ErrMessagePayloadTooLarge = fmt.Errorf("Message payload exceeds size of %d", 0xFFFF)
 )
@@ -0,0 +1,96 @@
+package smpp
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrConnectionLost indicates bound connection is closed unexpectedly, see SessionEvent.Err.
+	ErrConnectionLost = fmt.Errorf("connection to SMSC is lost")
+)
+
+// SessionState is lifecycle state of Session.
+type SessionState int32
+
+const (
+	// StateConnecting indicates Session is dialing SMSC.
+	StateConnecting SessionState = iota
+
+	// StateBinding indicates bind request is issued and Session waits for bind_resp.
+	StateBinding
+
+	// StateBound indicates Session is bound and able to submit.
+	StateBound
+
+	// StateRebinding indicates bound connection is lost and Session waits for next rebinding attempt.
+	StateRebinding
+
+	// StateUnbinding indicates Session is shutting down, see Session.Shutdown.
+	StateUnbinding
+
+	// StateClosed indicates Session is closed for good.
+	StateClosed
+)
+
+// String interface.
+func (s SessionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "Connecting"
+
+	case StateBinding:
+		return "Binding"
+
+	case StateBound:
+		return "Bound"
+
+	case StateRebinding:
+		return "Rebinding"
+
+	case StateUnbinding:
+		return "Unbinding"
+
+	case StateClosed:
+		return "Closed"
+
+	default:
+		return ""
+	}
+}
+
+// SessionEvent notifies transition of Session lifecycle state.
+type SessionEvent struct {
+	SessionID string
+	State     SessionState
+	Previous  SessionState
+	Time      time.Time
+
+	// SystemID is system_id from bind_resp, set on StateBound.
+	SystemID string
+
+	// Err is cause of transition, e.g. ErrConnectionLost or failure of the last binding attempt.
+	Err error
+}
+
+// SessionEventCallback notifies transition of Session lifecycle state.
+type SessionEventCallback func(SessionEvent)
+
+type bindingHookKey struct{}
+
+// withBindingHook returns ctx carrying hook called once connection is established and bind request is about to be issued.
+func withBindingHook(ctx context.Context, hook func()) context.Context {
+	return context.WithValue(ctx, bindingHookKey{}, hook)
+}
+
+func callBindingHook(ctx context.Context) {
+	if hook, ok := ctx.Value(bindingHookKey{}).(func()); ok {
+		hook()
+	}
+}
+
+// connectionLost returns cause of rebinding due to closing state.
+func connectionLost(state State) error {
+	return fmt.Errorf("%w: %s", ErrConnectionLost, state.String())
+}
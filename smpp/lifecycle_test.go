@@ -0,0 +1,211 @@
+package smpp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// eventRecorder collects session events.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []SessionEvent
+}
+
+func (r *eventRecorder) record(e SessionEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+func (r *eventRecorder) states() (states []SessionState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.events {
+		states = append(states, e.State)
+	}
+	return
+}
+
+func (r *eventRecorder) last() SessionEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.events[len(r.events)-1]
+}
+
+func TestSessionState(t *testing.T) {
+	require.Equal(t, "Connecting", StateConnecting.String())
+	require.Equal(t, "Closed", StateClosed.String())
+	require.Equal(t, "", SessionState(100).String())
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	t.Run("rebind and close", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		var events eventRecorder
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout:   time.Second,
+			OnStateChange: events.record,
+		}, 10*time.Millisecond)
+		require.NoError(t, err)
+
+		require.Equal(t, StateBound, session.State())
+		require.Equal(t, []SessionState{StateConnecting, StateBinding, StateBound}, events.states())
+		require.Equal(t, session.ID, events.last().SessionID)
+		require.Equal(t, smsctest.DefaultSystemID, events.last().SystemID)
+		require.False(t, events.last().Time.IsZero())
+
+		smsc.CloseConnections()
+		require.Eventually(t, func() bool {
+			return len(events.states()) == 7
+		}, time.Second, 10*time.Millisecond)
+		require.Equal(t, []SessionState{StateRebinding, StateConnecting, StateBinding, StateBound}, events.states()[3:])
+
+		events.mu.Lock()
+		require.ErrorIs(t, events.events[3].Err, ErrConnectionLost)
+		events.mu.Unlock()
+
+		require.NoError(t, session.Close())
+		require.Equal(t, StateClosed, session.State())
+		require.True(t, session.IsClosed())
+		require.Equal(t, StateBound, events.last().Previous)
+		require.Len(t, events.states(), 8)
+	})
+
+	t.Run("rebinding error", func(t *testing.T) {
+		var status sync.Map
+		status.Store("status", data.ESME_ROK)
+
+		smsc := smsctest.NewServer(smsctest.Config{
+			Authenticate: func(*pdu.BindRequest) data.CommandStatusType {
+				v, _ := status.Load("status")
+				return v.(data.CommandStatusType)
+			},
+		})
+		defer smsc.Close()
+
+		var events eventRecorder
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout:   time.Second,
+			OnStateChange: events.record,
+			RebindPolicy: &BackoffRebindPolicy{
+				InitialInterval: 10 * time.Millisecond,
+				StopOnStatuses:  PermanentBindStatuses,
+			},
+		}, 0)
+		require.NoError(t, err)
+
+		status.Store("status", data.ESME_RINVPASWD)
+		smsc.CloseConnections()
+
+		require.Eventually(t, session.IsClosed, time.Second, 10*time.Millisecond)
+
+		var bindErr *BindError
+		require.ErrorAs(t, events.last().Err, &bindErr)
+		require.Equal(t, data.ESME_RINVPASWD, bindErr.Status)
+		require.Equal(t, []SessionState{StateRebinding, StateConnecting, StateBinding, StateRebinding, StateClosed}, events.states()[3:])
+	})
+
+	t.Run("without rebinding", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		var events eventRecorder
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout:   time.Second,
+			OnStateChange: events.record,
+		}, 0)
+		require.NoError(t, err)
+		defer func() {
+			_ = session.Close()
+		}()
+
+		smsc.CloseConnections()
+		require.Eventually(t, session.IsClosed, time.Second, 10*time.Millisecond)
+		require.ErrorIs(t, events.last().Err, ErrConnectionLost)
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		var events eventRecorder
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout:   time.Second,
+			OnStateChange: events.record,
+		}, time.Second)
+		require.NoError(t, err)
+
+		_, err = session.Shutdown(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []SessionState{StateUnbinding, StateClosed}, events.states()[3:])
+	})
+
+	t.Run("failed to bind", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{
+			Authenticate: func(*pdu.BindRequest) data.CommandStatusType {
+				return data.ESME_RINVSYSID
+			},
+		})
+		defer smsc.Close()
+
+		var events eventRecorder
+		_, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout:   time.Second,
+			OnStateChange: events.record,
+		}, time.Second)
+		require.Error(t, err)
+		require.Equal(t, []SessionState{StateConnecting, StateBinding, StateClosed}, events.states())
+		require.Equal(t, err, events.last().Err)
+	})
+}
+
+func TestManagerSessionStates(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	var events eventRecorder
+	m, err := NewManager(Setting{
+		URL:            smsc.Addr(),
+		Dialer:         smsc.Dial,
+		ReadTimeout:    time.Second,
+		EnquiryTimeout: 10 * time.Millisecond,
+		MaxConnection:  2,
+		OnSessionEvent: events.record,
+		Logger:         &recordLogger{},
+	})
+	require.NoError(t, err)
+	require.False(t, m.Ready())
+
+	require.NoError(t, m.AddConnection(2))
+	defer func() {
+		_ = m.Close()
+	}()
+
+	require.True(t, m.Ready())
+	states := m.SessionStates()
+	require.Len(t, states, 2)
+	for _, state := range states {
+		require.Equal(t, StateBound, state)
+	}
+	require.Len(t, events.states(), 6)
+
+	require.NoError(t, m.Close())
+	require.False(t, m.Ready())
+	for _, state := range m.SessionStates() {
+		require.Equal(t, StateClosed, state)
+	}
+
+	require.NoError(t, m.RemoveConnection())
+	require.Empty(t, m.SessionStates())
+}
@@ -0,0 +1,206 @@
+package smpp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrLimiterBurst indicates a limiter whose burst can never be satisfied.
+	ErrLimiterBurst = fmt.Errorf("rate limiter burst is zero")
+)
+
+// Limiter is consulted before each PDU is submitted. It may be shared by sessions,
+// e.g. all sessions of a Manager, or coordinate several processes through external backend.
+//
+// Implementations must be safe for concurrent use.
+type Limiter interface {
+	// Wait blocks until p may be submitted or ctx is done.
+	Wait(ctx context.Context, p pdu.PDU) error
+}
+
+// LimiterFunc adapts function to Limiter.
+type LimiterFunc func(ctx context.Context, p pdu.PDU) error
+
+// Wait implements Limiter.
+func (f LimiterFunc) Wait(ctx context.Context, p pdu.PDU) error {
+	return f(ctx, p)
+}
+
+// Quota is rate limit in messages per second. Zero Rate is unlimited, zero Burst defaults to 1.
+type Quota struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+func (q Quota) limiter() *rate.Limiter {
+	if q.Rate <= 0 {
+		return nil
+	}
+
+	burst := q.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return rate.NewLimiter(q.Rate, burst)
+}
+
+// QuotaSettings for QuotaLimiter.
+type QuotaSettings struct {
+	// Total limits all messages.
+	Total Quota
+
+	// DestinationPrefixes limit messages by the longest prefix matching destination address.
+	// Submit_multi waits for each of its destinations.
+	DestinationPrefixes map[string]Quota
+
+	// Senders limit messages by source address.
+	Senders map[string]Quota
+}
+
+// QuotaLimiter limits messages (submit_sm, submit_multi, data_sm) by total, per-destination-prefix
+// and per-sender quotas. Message waits until all matching quotas allow it. Other PDUs are not limited.
+type QuotaLimiter struct {
+	total    *rate.Limiter
+	prefixes []string // longest first
+	byPrefix map[string]*rate.Limiter
+	senders  map[string]*rate.Limiter
+}
+
+// NewQuotaLimiter creates QuotaLimiter.
+func NewQuotaLimiter(settings QuotaSettings) *QuotaLimiter {
+	q := &QuotaLimiter{
+		total:    settings.Total.limiter(),
+		byPrefix: make(map[string]*rate.Limiter),
+		senders:  make(map[string]*rate.Limiter),
+	}
+
+	for prefix, quota := range settings.DestinationPrefixes {
+		if l := quota.limiter(); l != nil {
+			q.byPrefix[prefix] = l
+			q.prefixes = append(q.prefixes, prefix)
+		}
+	}
+	sort.Slice(q.prefixes, func(i, j int) bool {
+		if len(q.prefixes[i]) != len(q.prefixes[j]) {
+			return len(q.prefixes[i]) > len(q.prefixes[j])
+		}
+		return q.prefixes[i] < q.prefixes[j]
+	})
+
+	for sender, quota := range settings.Senders {
+		if l := quota.limiter(); l != nil {
+			q.senders[sender] = l
+		}
+	}
+	return q
+}
+
+// Wait implements Limiter.
+func (q *QuotaLimiter) Wait(ctx context.Context, p pdu.PDU) error {
+	if !isMessage(p) {
+		return nil
+	}
+
+	var limiters []*rate.Limiter
+	add := func(l *rate.Limiter) {
+		if l == nil {
+			return
+		}
+		for _, v := range limiters {
+			if v == l {
+				return
+			}
+		}
+		limiters = append(limiters, l)
+	}
+
+	add(q.total)
+
+	source, destinations := messageAddresses(p)
+	add(q.senders[source])
+	for _, dest := range destinations {
+		add(q.destination(dest))
+	}
+
+	return waitLimiters(ctx, limiters)
+}
+
+// destination returns limiter of the longest prefix matching address.
+func (q *QuotaLimiter) destination(addr string) *rate.Limiter {
+	for _, prefix := range q.prefixes {
+		if strings.HasPrefix(addr, prefix) {
+			return q.byPrefix[prefix]
+		}
+	}
+	return nil
+}
+
+// messageAddresses returns source and destination addresses of message.
+func messageAddresses(p pdu.PDU) (source string, destinations []string) {
+	switch v := p.(type) {
+	case *pdu.SubmitSM:
+		return v.SourceAddr.Address(), []string{v.DestAddr.Address()}
+
+	case *pdu.DataSM:
+		return v.SourceAddr.Address(), []string{v.DestAddr.Address()}
+
+	case *pdu.SubmitMulti:
+		for _, dest := range v.DestAddrs.Get() {
+			if dest.IsAddress() {
+				addr := dest.Address()
+				destinations = append(destinations, addr.Address())
+			}
+		}
+		return v.SourceAddr.Address(), destinations
+	}
+	return
+}
+
+// waitLimiters reserves a token of each limiter at once and waits for the latest of them,
+// so that tokens are not held while waiting for another limiter.
+// Reservations are cancelled if ctx is done first.
+func waitLimiters(ctx context.Context, limiters []*rate.Limiter) error {
+	if len(limiters) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	reservations := make([]*rate.Reservation, 0, len(limiters))
+	cancel := func() {
+		for _, r := range reservations {
+			r.Cancel()
+		}
+	}
+
+	var delay time.Duration
+	for _, l := range limiters {
+		r := l.ReserveN(now, 1)
+		if !r.OK() {
+			cancel()
+			return ErrLimiterBurst
+		}
+		reservations = append(reservations, r)
+
+		if d := r.DelayFrom(now); d > delay {
+			delay = d
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(delay).After(deadline) {
+		cancel()
+		return context.DeadlineExceeded
+	}
+
+	if err := sleep(ctx, delay); err != nil {
+		cancel()
+		return err
+	}
+	return nil
+}
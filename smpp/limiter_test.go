@@ -0,0 +1,154 @@
+package smpp
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func newMessage(from, to string) *pdu.SubmitSM {
+	p := pdu.NewSubmitSM().(*pdu.SubmitSM)
+	_ = p.SourceAddr.SetAddress(from)
+	_ = p.DestAddr.SetAddress(to)
+	return p
+}
+
+// waitTime returns duration of waiting for n messages.
+func waitTime(t *testing.T, l Limiter, n int, p pdu.PDU) time.Duration {
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		require.NoError(t, l.Wait(context.Background(), p))
+	}
+	return time.Since(start)
+}
+
+func TestQuotaLimiter(t *testing.T) {
+	l := NewQuotaLimiter(QuotaSettings{
+		Total: Quota{Rate: 1000},
+		DestinationPrefixes: map[string]Quota{
+			"44":   {Rate: 1000},
+			"4477": {Rate: 20},
+		},
+		Senders: map[string]Quota{
+			"bank": {Rate: 20, Burst: 2},
+		},
+	})
+
+	require.Less(t, waitTime(t, l, 5, newMessage("shop", "4420")), 50*time.Millisecond)
+	require.Less(t, waitTime(t, l, 5, pdu.NewEnquireLink()), 10*time.Millisecond)
+
+	// the longest prefix applies
+	require.GreaterOrEqual(t, waitTime(t, l, 3, newMessage("shop", "447700")), 90*time.Millisecond)
+
+	// burst of sender
+	require.Less(t, waitTime(t, l, 2, newMessage("bank", "1")), 40*time.Millisecond)
+	require.GreaterOrEqual(t, waitTime(t, l, 2, newMessage("bank", "1")), 90*time.Millisecond)
+
+	multi := pdu.NewSubmitMulti().(*pdu.SubmitMulti)
+	for _, to := range []string{"447701", "447702"} {
+		addr := pdu.NewAddress()
+		_ = addr.SetAddress(to)
+		dest := pdu.NewDestinationAddress()
+		dest.SetAddress(addr)
+		multi.DestAddrs.Add(dest)
+	}
+	source, destinations := messageAddresses(multi)
+	require.Equal(t, "", source)
+	require.Equal(t, []string{"447701", "447702"}, destinations)
+
+	// deadline shorter than the wait releases reservations
+	require.NoError(t, l.Wait(context.Background(), newMessage("shop", "447700")))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.Wait(ctx, newMessage("shop", "447700")), context.DeadlineExceeded)
+	require.ErrorIs(t, l.Wait(ctx, newMessage("shop", "447700")), context.DeadlineExceeded)
+}
+
+func TestWaitLimiters(t *testing.T) {
+	require.NoError(t, waitLimiters(context.Background(), nil))
+	require.ErrorIs(t, waitLimiters(context.Background(), []*rate.Limiter{rate.NewLimiter(1, 0)}), ErrLimiterBurst)
+
+	// waiting for the slowest limiter does not hold token of the other one
+	fast, slow := rate.NewLimiter(rate.Inf, 1), rate.NewLimiter(10, 1)
+	require.NoError(t, waitLimiters(context.Background(), []*rate.Limiter{fast, slow}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, waitLimiters(ctx, []*rate.Limiter{rate.NewLimiter(1000, 1), slow}), context.Canceled)
+	require.True(t, fast.Allow())
+}
+
+func TestManagerSharedLimiter(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	m, err := NewManager(Setting{
+		URL:           smsc.Addr(),
+		Dialer:        smsc.Dial,
+		ReadTimeout:   time.Second,
+		MaxConnection: 2,
+		Throttle:      20,
+		Logger:        &recordLogger{},
+	})
+	require.NoError(t, err)
+	require.NoError(t, m.AddConnection(2))
+	defer func() {
+		_ = m.Close()
+	}()
+
+	sessions := make([]*Session, 0, 2)
+	for _, s := range m.connections {
+		sessions = append(sessions, s)
+	}
+	require.Len(t, sessions, 2)
+
+	// 6 messages over 2 sessions take 5 intervals of the pool
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, s := range sessions {
+		wg.Add(1)
+		go func(s *Session) {
+			defer wg.Done()
+			for i := 0; i < 3; i++ {
+				_, err := s.Transceiver().SubmitResp(context.Background(), newSubmitSM("esme"))
+				require.NoError(t, err)
+			}
+		}(s)
+	}
+	wg.Wait()
+	require.GreaterOrEqual(t, time.Since(start), 240*time.Millisecond)
+	require.Len(t, smsc.ReceivedOf(data.SUBMIT_SM), 6)
+}
+
+func TestSessionLimiter(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	var waits int32
+	session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+		ReadTimeout: time.Second,
+		Limiter: LimiterFunc(func(_ context.Context, p pdu.PDU) error {
+			if isMessage(p) {
+				atomic.AddInt32(&waits, 1)
+			}
+			return nil
+		}),
+	}, 0)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+
+	_, err = session.Transceiver().SubmitResp(context.Background(), newSubmitSM("esme"))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&waits))
+}
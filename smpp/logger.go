@@ -0,0 +1,144 @@
+package smpp
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+// Logger is structured logger. Arguments are alternating keys and values.
+//
+// *slog.Logger satisfies this interface.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// LogLevel is severity of log record, aligned with slog levels.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = -4
+	LevelInfo  LogLevel = 0
+	LevelWarn  LogLevel = 4
+	LevelError LogLevel = 8
+)
+
+// String interface.
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+
+	case LevelInfo:
+		return "INFO"
+
+	case LevelWarn:
+		return "WARN"
+
+	case LevelError:
+		return "ERROR"
+
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+// NewStdLogger returns Logger writing records at or above level to standard logger,
+// formatted as `LEVEL msg key=value ...`. Nil l uses log.Default().
+func NewStdLogger(l *log.Logger, level LogLevel) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return &stdLogger{logger: l, level: level}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+func (l *stdLogger) Debug(msg string, args ...interface{}) { l.log(LevelDebug, msg, args) }
+func (l *stdLogger) Info(msg string, args ...interface{})  { l.log(LevelInfo, msg, args) }
+func (l *stdLogger) Warn(msg string, args ...interface{})  { l.log(LevelWarn, msg, args) }
+func (l *stdLogger) Error(msg string, args ...interface{}) { l.log(LevelError, msg, args) }
+
+func (l *stdLogger) log(level LogLevel, msg string, args []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		b.WriteByte(' ')
+		if i+1 < len(args) {
+			_, _ = fmt.Fprintf(&b, "%v=%v", args[i], args[i+1])
+		} else {
+			_, _ = fmt.Fprintf(&b, "!BADKEY=%v", args[i])
+		}
+	}
+	l.logger.Println(b.String())
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+// fieldLogger prepends fixed fields to each record.
+type fieldLogger struct {
+	logger Logger
+	fields []interface{}
+}
+
+// withFields returns logger attaching key-value fields to each record. Nil l discards records.
+func withFields(l Logger, fields ...interface{}) Logger {
+	switch v := l.(type) {
+	case nil:
+		return nopLogger{}
+
+	case nopLogger:
+		return v
+
+	case *fieldLogger:
+		return &fieldLogger{logger: v.logger, fields: append(append([]interface{}{}, v.fields...), fields...)}
+
+	default:
+		return &fieldLogger{logger: l, fields: fields}
+	}
+}
+
+func (l *fieldLogger) Debug(msg string, args ...interface{}) { l.logger.Debug(msg, l.args(args)...) }
+func (l *fieldLogger) Info(msg string, args ...interface{})  { l.logger.Info(msg, l.args(args)...) }
+func (l *fieldLogger) Warn(msg string, args ...interface{})  { l.logger.Warn(msg, l.args(args)...) }
+func (l *fieldLogger) Error(msg string, args ...interface{}) { l.logger.Error(msg, l.args(args)...) }
+
+func (l *fieldLogger) args(args []interface{}) []interface{} {
+	return append(append(make([]interface{}, 0, len(l.fields)+len(args)), l.fields...), args...)
+}
+
+// pduFields returns log fields describing PDU, with hex dump if requested.
+func pduFields(p pdu.PDU, hexDump bool) []interface{} {
+	h := p.GetHeader()
+	fields := []interface{}{
+		"command_id", h.CommandID.String(),
+		"sequence_number", h.SequenceNumber,
+		"command_status", h.CommandStatus.String(),
+	}
+
+	if hexDump {
+		buf := pdu.NewBuffer(make([]byte, 0, 64))
+		p.Marshal(buf)
+		fields = append(fields, "hex", buf.HexDump())
+	}
+	return fields
+}
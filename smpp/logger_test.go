@@ -0,0 +1,105 @@
+package smpp
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordLogger keeps records formatted as `LEVEL msg key=value ...`.
+type recordLogger struct {
+	mu      sync.Mutex
+	records []string
+}
+
+func (l *recordLogger) Debug(msg string, args ...interface{}) { l.log(LevelDebug, msg, args) }
+func (l *recordLogger) Info(msg string, args ...interface{})  { l.log(LevelInfo, msg, args) }
+func (l *recordLogger) Warn(msg string, args ...interface{})  { l.log(LevelWarn, msg, args) }
+func (l *recordLogger) Error(msg string, args ...interface{}) { l.log(LevelError, msg, args) }
+
+func (l *recordLogger) log(level LogLevel, msg string, args []interface{}) {
+	record := level.String() + " " + msg
+	for i := 0; i+1 < len(args); i += 2 {
+		record += fmt.Sprintf(" %v=%v", args[i], args[i+1])
+	}
+
+	l.mu.Lock()
+	l.records = append(l.records, record)
+	l.mu.Unlock()
+}
+
+// find returns the first record containing all parts.
+func (l *recordLogger) find(parts ...string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+next:
+	for _, record := range l.records {
+		for _, part := range parts {
+			if !strings.Contains(record, part) {
+				continue next
+			}
+		}
+		return record
+	}
+	return ""
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0), LevelInfo)
+
+	logger.Debug("hidden")
+	logger.Info("bound", "system_id", "smsc", "attempt", 1)
+	logger.Error("odd", "key")
+
+	require.Equal(t, "INFO bound system_id=smsc attempt=1\nERROR odd !BADKEY=key\n", buf.String())
+}
+
+func TestWithFields(t *testing.T) {
+	var l recordLogger
+	logger := withFields(withFields(&l, "session_id", "abc"), "system_id", "smsc")
+	logger.Warn("failed", "error", "eof")
+
+	require.Equal(t, []string{"WARN failed session_id=abc system_id=smsc error=eof"}, l.records)
+	require.IsType(t, nopLogger{}, withFields(nil, "key", "value"))
+}
+
+func TestSessionLogger(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	var l recordLogger
+	session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+		ReadTimeout: time.Second,
+		Logger:      &l,
+		LogHexDump:  true,
+	}, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+
+	require.NoError(t, session.Transceiver().Submit(newSubmitSM("esme")))
+	require.Eventually(t, func() bool {
+		return l.find("DEBUG received PDU", "command_id=SUBMIT_SM_RESP") != ""
+	}, time.Second, 10*time.Millisecond)
+
+	id := "session_id=" + session.ID
+	require.NotEmpty(t, l.find("INFO session state changed", id, "state=Bound", "system_id="+smsctest.DefaultSystemID))
+	require.NotEmpty(t, l.find("DEBUG submitting PDU", id, "system_id="+smsctest.DefaultSystemID, "command_id=SUBMIT_SM", "hex=0000"))
+
+	smsc.CloseConnections()
+	require.Eventually(t, func() bool {
+		return l.find("INFO rebinding", id, "attempt=1") != ""
+	}, time.Second, 10*time.Millisecond)
+	require.NotEmpty(t, l.find("WARN session state changed", "state=Rebinding", "error="))
+}
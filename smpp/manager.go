@@ -2,14 +2,11 @@ package smpp
 
 import (
 	"context"
-	"fmt"
 	"github.com/go-errors/errors"
 	"github.com/sujit-baniya/protocol/smpp/balancer"
-	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
 	"github.com/sujit-baniya/protocol/smpp/pdu"
-	"log"
 	"math/rand"
-	"net"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +14,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/rs/xid"
+	"golang.org/x/time/rate"
 )
 
 type ConnectionInterface interface {
@@ -40,17 +38,55 @@ type Setting struct {
 	Slug             string
 	URL              string
 	Auth             Auth
+	Dialer           Dialer
 	ReadTimeout      time.Duration
 	WriteTimeout     time.Duration
 	EnquiryInterval  time.Duration
 	EnquiryTimeout   time.Duration
 	MaxConnection    int
 	Balancer         balancer.Balancer
-	Throttle         int
+	Throttle         int // messages per second of the whole pool, see Limiter
 	UseAllConnection bool
 	HandlePDU        func(con *Session)
 	OnPDU            PDUCallback
 	AutoRebind       bool
+	Segmentation     pdu.Segmentation
+
+	// OnSessionEvent notifies lifecycle transitions of all sessions in the pool.
+	OnSessionEvent SessionEventCallback
+
+	// Logger is passed to pooled sessions, see Settings.Logger.
+	// Nil logs at info level to standard logger.
+	Logger Logger
+
+	// LogHexDump adds hex dump of PDUs to debug logs, see Settings.LogHexDump.
+	LogHexDump bool
+
+	// Metrics receives instrumentation of pooled sessions, see Settings.Metrics.
+	// Nil collects metrics of all pooled sessions into one MemoryMetrics, see Manager.Metrics.
+	Metrics Metrics
+
+	// Limiter is shared by all pooled sessions, e.g. QuotaLimiter with per-destination-prefix and
+	// per-sender quotas, or Limiter coordinating several processes.
+	// Nil limits the pool to Throttle messages per second in total if Throttle is set.
+	Limiter Limiter
+
+	// WriteBatch coalesces writes of each pooled session, see Settings.WriteBatch.
+	WriteBatch *WriteBatchSettings
+
+	// AdaptiveRate adjusts rate of each pooled session, see Settings.AdaptiveRate.
+	AdaptiveRate *AdaptiveRateSettings
+
+	// Tracer traces pooled sessions, see Settings.Tracer. Delivery receipt is linked
+	// to its submit_sm even if it arrives over another session.
+	Tracer Tracer
+
+	// Callbacks passed to pooled sessions, see Settings. Events they notify
+	// are logged to Logger regardless.
+	OnSubmitError    PDUErrorCallback
+	OnReceivingError ErrorCallback
+	OnRebindingError ErrorCallback
+	OnClosed         ClosedCallback
 }
 
 type Manager struct {
@@ -63,6 +99,12 @@ type Manager struct {
 	Balancer    balancer.Balancer
 	connIDs     []string
 	mu          sync.RWMutex
+
+	states  map[string]SessionState
+	stateMu sync.RWMutex
+	logger  Logger
+
+	receipts *receiptLinks
 }
 
 type HandlePDU func(conn *Session)
@@ -74,6 +116,9 @@ type Message struct {
 }
 
 func NewManager(setting Setting) (*Manager, error) {
+	if setting.Metrics == nil {
+		setting.Metrics = NewMemoryMetrics(nil)
+	}
 	if setting.MaxConnection == 0 {
 		setting.MaxConnection = 1
 	}
@@ -84,13 +129,36 @@ func NewManager(setting Setting) (*Manager, error) {
 		ctx:         context.Background(),
 		setting:     setting,
 		connections: make(map[string]*Session),
+		states:      make(map[string]SessionState),
 	}
 	if setting.Balancer == nil {
 		manager.Balancer = &balancer.RoundRobin{}
 	}
+
+	logger := setting.Logger
+	if logger == nil {
+		logger = NewStdLogger(nil, LevelInfo)
+	}
+	manager.logger = withFields(logger, "manager_id", manager.ID)
+
+	// throttle is contracted for the whole pool rather than per session
+	if setting.Limiter == nil && setting.Throttle > 0 {
+		manager.setting.Limiter = NewQuotaLimiter(QuotaSettings{
+			Total: Quota{Rate: rate.Limit(setting.Throttle)},
+		})
+	}
+
+	if setting.Tracer != nil {
+		manager.receipts = newReceiptLinks(DefaultTraceReceiptLinks)
+	}
 	return manager, nil
 }
 
+// Metrics returns instrumentation shared by pooled sessions.
+func (m *Manager) Metrics() Metrics {
+	return m.setting.Metrics
+}
+
 func (m *Manager) Start() error {
 	if m.setting.UseAllConnection {
 		for i := 0; i < m.setting.MaxConnection; i++ {
@@ -147,6 +215,7 @@ func (m *Manager) RemoveConnection(conID ...string) error {
 				}
 				m.connIDs = remove(m.connIDs, id)
 				delete(m.connections, id)
+				m.forgetState(id)
 			}
 		}
 	} else {
@@ -157,6 +226,7 @@ func (m *Manager) RemoveConnection(conID ...string) error {
 			}
 			m.connIDs = remove(m.connIDs, id)
 			delete(m.connections, id)
+			m.forgetState(id)
 		}
 	}
 	return nil
@@ -169,6 +239,9 @@ func (m *Manager) Rebind() error {
 	}
 	m.connections = make(map[string]*Session)
 	m.connIDs = []string{}
+	m.stateMu.Lock()
+	m.states = make(map[string]SessionState)
+	m.stateMu.Unlock()
 	err = m.Start()
 	if err != nil {
 		return err
@@ -179,36 +252,53 @@ func (m *Manager) Rebind() error {
 func (m *Manager) SetupConnection() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	_, err := net.Dial("tcp", m.setting.URL)
-	if err != nil {
-		return err
+
+	dialer := m.setting.Dialer
+	if dialer == nil {
+		dialer = NonTLSDialer
+	}
+
+	auth := m.setting.Auth
+	if auth.SMSC == "" {
+		auth.SMSC = m.setting.URL
 	}
 
 	smppSetting := Settings{
 		EnquireLink:  m.setting.EnquiryInterval,
 		WriteTimeout: m.setting.WriteTimeout,
+		WriteBatch:   m.setting.WriteBatch,
 		ReadTimeout:  m.setting.ReadTimeout,
 
-		OnSubmitError: func(_ pdu.PDU, err error) {
-			log.Fatal("SubmitPDU error:", err)
-		},
-
-		OnReceivingError: func(err error) {
-			fmt.Println("Receiving PDU/Network error:", err)
-		},
-
-		OnRebindingError: func(err error) {
-			fmt.Println("Rebinding but error:", err)
-		},
+		OnSubmitError:    m.setting.OnSubmitError,
+		OnReceivingError: m.setting.OnReceivingError,
+		OnRebindingError: m.setting.OnRebindingError,
+		OnClosed:         m.setting.OnClosed,
 
 		OnPDU: m.setting.OnPDU,
 
-		OnClosed: func(state State) {
-			fmt.Println(state)
-		},
+		Logger:       m.logger,
+		LogHexDump:   m.setting.LogHexDump,
+		Metrics:      m.setting.Metrics,
+		Tracer:       m.setting.Tracer,
+		AdaptiveRate: m.setting.AdaptiveRate,
+		Limiter:      m.setting.Limiter,
+		receipts:     m.receipts,
 	}
-	conn, err := NewSession(TRXConnector(NonTLSDialer, m.setting.Auth), smppSetting, m.setting.EnquiryTimeout)
+
+	// first event is emitted before NewSession returns
+	var sessionID string
+	var once sync.Once
+	smppSetting.OnStateChange = func(e SessionEvent) {
+		once.Do(func() {
+			sessionID = e.SessionID
+		})
+		m.onSessionEvent(e)
+	}
+
+	conn, err := NewSession(TRXConnector(dialer, auth), smppSetting, m.setting.EnquiryTimeout)
 	if err != nil {
+		// session is never pooled
+		m.forgetState(sessionID)
 		return err
 	}
 	m.connIDs = append(m.connIDs, conn.ID)
@@ -216,6 +306,48 @@ func (m *Manager) SetupConnection() error {
 	return nil
 }
 
+// onSessionEvent tracks state of pooled session and forwards event to Setting.OnSessionEvent.
+func (m *Manager) onSessionEvent(e SessionEvent) {
+	m.stateMu.Lock()
+	m.states[e.SessionID] = e.State
+	m.stateMu.Unlock()
+
+	if m.setting.OnSessionEvent != nil {
+		m.setting.OnSessionEvent(e)
+	}
+}
+
+func (m *Manager) forgetState(id string) {
+	m.stateMu.Lock()
+	delete(m.states, id)
+	m.stateMu.Unlock()
+}
+
+// SessionStates returns lifecycle state of each pooled session, by session ID.
+func (m *Manager) SessionStates() map[string]SessionState {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+
+	states := make(map[string]SessionState, len(m.states))
+	for id, state := range m.states {
+		states[id] = state
+	}
+	return states
+}
+
+// Ready returns true if at least one pooled session is bound.
+func (m *Manager) Ready() bool {
+	m.stateMu.RLock()
+	defer m.stateMu.RUnlock()
+
+	for _, state := range m.states {
+		if state == StateBound {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *Manager) GetConnection(conIds ...string) (*Session, error) {
 	var pickedID string
 	if len(conIds) > 0 { // pick among custom
@@ -246,17 +378,17 @@ type SmppResponse struct {
 
 func (m *Manager) Send(payload interface{}, connectionId ...string) (interface{}, error) {
 	sms := payload.(Message)
-	shortMessages, err := m.Compose(sms.Message)
+	submitSMs, err := m.ComposeMessage(sms)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	var responses []SmppResponse
 	responseChan := make(chan map[*pdu.SubmitSM]*pdu.SubmitSMResp)
 	wg := &sync.WaitGroup{}
-	for _, shortMessage := range shortMessages {
+	for _, submitSM := range submitSMs {
 		wg.Add(1)
-		go m.SendShortMessage(sms.From, sms.To, shortMessage, wg, responseChan, connectionId...)
+		go m.SendSubmitSM(submitSM, wg, responseChan, connectionId...)
 	}
 	go func() {
 		wg.Wait()
@@ -273,32 +405,47 @@ func (m *Manager) Send(payload interface{}, connectionId ...string) (interface{}
 	return responses, nil
 }
 
-func (m *Manager) SendShortMessage(from string, to string, shortMessage pdu.ShortMessage, wg *sync.WaitGroup, responseChan chan<- map[*pdu.SubmitSM]*pdu.SubmitSMResp, connectionId ...string) error {
+func (m *Manager) SendSubmitSM(submitSM *pdu.SubmitSM, wg *sync.WaitGroup, responseChan chan<- map[*pdu.SubmitSM]*pdu.SubmitSMResp, connectionId ...string) error {
 	defer wg.Done()
 	conn, err := m.GetConnection(connectionId...)
 	if err != nil {
 		return err
 	}
-	packet := m.Prepare(from, to, shortMessage)
 	err = conn.Wait()
 	if err != nil {
 		return err
 	}
-	err = conn.Transceiver().Submit(packet)
+	err = conn.Transceiver().Submit(submitSM)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// SendShortMessage submits shortMessage from/to given phones.
+//
+// Deprecated: use SendSubmitSM with PDUs of ComposeMessage.
+func (m *Manager) SendShortMessage(from string, to string, shortMessage pdu.ShortMessage, wg *sync.WaitGroup, responseChan chan<- map[*pdu.SubmitSM]*pdu.SubmitSMResp, connectionId ...string) error {
+	return m.SendSubmitSM(m.Prepare(from, to, shortMessage), wg, responseChan, connectionId...)
+}
+
+// Prepare returns SubmitSM carrying shortMessage from/to given phones.
 func (m *Manager) Prepare(from string, to string, shortMessage pdu.ShortMessage) *pdu.SubmitSM {
-	return &pdu.SubmitSM{
-		SourceAddr:         parseSrcPhone(from),
-		DestAddr:           parseDestPhone(to),
-		RegisteredDelivery: 1,
-		Message:            shortMessage,
-		EsmClass:           0,
+	submitSM := m.PrepareSubmitSM(from, to)
+	submitSM.Message = shortMessage
+	if shortMessage.UDH() != nil {
+		submitSM.EsmClass |= data.SM_UDH_GSM
 	}
+	return submitSM
+}
+
+// PrepareSubmitSM returns SubmitSM template addressed from/to given phones.
+func (m *Manager) PrepareSubmitSM(from string, to string) *pdu.SubmitSM {
+	submitSM := pdu.NewSubmitSM().(*pdu.SubmitSM)
+	submitSM.SourceAddr = parseSrcPhone(from)
+	submitSM.DestAddr = parseDestPhone(to)
+	submitSM.RegisteredDelivery = 1
+	return submitSM
 }
 
 func (m *Manager) Close(connectionId ...string) error {
@@ -328,14 +475,42 @@ func (m *Manager) HandlePDU() error {
 	return nil
 }
 
+// Compose splits msg into short messages, see Compose.
+//
+// Deprecated: use ComposeMessage, which honours Setting.Segmentation.
 func (m *Manager) Compose(msg string) ([]pdu.ShortMessage, error) {
 	return Compose(msg)
 }
 
+// ComposeMessage splits message into ready to submit SubmitSM PDUs, using Setting.Segmentation.
+func (m *Manager) ComposeMessage(sms Message) ([]*pdu.SubmitSM, error) {
+	return ComposeSubmitSM(m.PrepareSubmitSM(sms.From, sms.To), sms.Message, m.setting.Segmentation)
+}
+
+// Compose splits msg into short messages with 8-bit reference concatenation UDH,
+// best safe encoding and random reference number.
+//
+// Deprecated: use ComposeSubmitSM.
 func Compose(msg string) ([]pdu.ShortMessage, error) {
-	reference := uint16(rand.Intn(0xFFFF))
-	dataCoding := coding.BestSafeCoding(msg)
-	return pdu.ComposeMultipartShortMessage(msg, dataCoding, reference)
+	multi, err := ComposeSubmitSM(nil, msg, pdu.SegmentationUDH8)
+	if err != nil {
+		return nil, err
+	}
+
+	shortMessages := make([]pdu.ShortMessage, 0, len(multi))
+	for _, submitSM := range multi {
+		shortMessages = append(shortMessages, submitSM.Message)
+	}
+	return shortMessages, nil
+}
+
+// ComposeSubmitSM splits msg into SubmitSM PDUs based on template, with best safe encoding
+// and random reference number.
+func ComposeSubmitSM(template *pdu.SubmitSM, msg string, segmentation pdu.Segmentation) ([]*pdu.SubmitSM, error) {
+	return pdu.ComposeMultipart(template, msg, pdu.MultipartOptions{
+		Segmentation: segmentation,
+		Reference:    uint16(rand.Intn(0x10000)),
+	})
 }
 
 func parseSrcPhone(phone string) pdu.Address {
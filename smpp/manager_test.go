@@ -0,0 +1,47 @@
+package smpp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagerCompose(t *testing.T) {
+	m, err := NewManager(Setting{Segmentation: pdu.SegmentationSAR})
+	require.NoError(t, err)
+
+	long := strings.Repeat("a", 200)
+
+	t.Run("ComposeMessage", func(t *testing.T) {
+		multi, err := m.ComposeMessage(Message{From: "+1234", To: "+5678", Message: long})
+		require.NoError(t, err)
+		require.Len(t, multi, 2)
+		for _, submitSM := range multi {
+			require.Equal(t, "+5678", submitSM.DestAddr.Address())
+			_, has := submitSM.OptionalParameters[pdu.TagSarMsgRefNum]
+			require.True(t, has)
+		}
+	})
+
+	t.Run("short messages", func(t *testing.T) {
+		shortMessages, err := m.Compose(long)
+		require.NoError(t, err)
+		require.Len(t, shortMessages, 2)
+
+		var text string
+		for _, sm := range shortMessages {
+			submitSM := m.Prepare("+1234", "+5678", sm)
+			require.NotZero(t, submitSM.EsmClass&data.SM_UDH_GSM)
+
+			parsed := wire(t, submitSM).(*pdu.SubmitSM)
+			part, err := parsed.Message.GetMessage()
+			require.NoError(t, err)
+			text += part
+		}
+		require.Equal(t, long, text)
+	})
+}
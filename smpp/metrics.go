@@ -0,0 +1,252 @@
+package smpp
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+)
+
+// DefaultLatencyBuckets are upper bounds, in seconds, of histograms kept by MemoryMetrics.
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics receives instrumentation of sessions. Each call carries ID of the session.
+//
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// PDUSent counts PDU written to SMSC.
+	PDUSent(session string, id data.CommandIDType)
+
+	// PDUReceived counts PDU read from SMSC.
+	PDUReceived(session string, id data.CommandIDType)
+
+	// ResponseStatus counts response received from SMSC by its command status.
+	ResponseStatus(session string, id data.CommandIDType, status data.CommandStatusType)
+
+	// ResponseLatency observes duration from submitting request until its response arrives.
+	ResponseLatency(session string, id data.CommandIDType, latency time.Duration)
+
+	// WindowOccupancy reports number of requests holding the window, see Settings.WindowSize.
+	WindowOccupancy(session string, occupied int)
+
+	// RebindAttempt counts rebinding attempt, err is nil if it succeeded.
+	RebindAttempt(session string, err error)
+
+	// EnquireLinkRTT observes round trip time of enquire_link.
+	EnquireLinkRTT(session string, rtt time.Duration)
+
+	// LimiterWait observes time spent waiting for rate limiter.
+	LimiterWait(session string, wait time.Duration)
+}
+
+// MetricKey identifies a series of MemoryMetrics. Fields not relevant to the series are zero.
+type MetricKey struct {
+	Session   string
+	CommandID data.CommandIDType
+	Status    data.CommandStatusType
+
+	// Success distinguishes succeeded rebinding attempts.
+	Success bool
+}
+
+// Histogram of observed durations.
+type Histogram struct {
+	// Buckets are upper bounds in seconds, Counts are cumulative numbers of observations within each bound.
+	Buckets []float64
+	Counts  []uint64
+
+	// Count is number of observations, Sum is their total in seconds.
+	Count uint64
+	Sum   float64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{Buckets: buckets, Counts: make([]uint64, len(buckets))}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	v := d.Seconds()
+	for i, bound := range h.Buckets {
+		if v <= bound {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += v
+}
+
+func (h *Histogram) clone() Histogram {
+	c := *h
+	c.Counts = append([]uint64(nil), h.Counts...)
+	return c
+}
+
+// MetricsSnapshot is point-in-time copy of MemoryMetrics.
+type MetricsSnapshot struct {
+	Sent      map[MetricKey]uint64
+	Received  map[MetricKey]uint64
+	Responses map[MetricKey]uint64
+	Rebinds   map[MetricKey]uint64
+
+	Window map[string]int
+
+	Latency        map[MetricKey]Histogram
+	EnquireLinkRTT map[MetricKey]Histogram
+	LimiterWait    map[MetricKey]Histogram
+}
+
+// MemoryMetrics keeps metrics in memory, see PrometheusHandler for exposing them.
+type MemoryMetrics struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	sent      map[MetricKey]uint64
+	received  map[MetricKey]uint64
+	responses map[MetricKey]uint64
+	rebinds   map[MetricKey]uint64
+	window    map[string]int
+	latency   map[MetricKey]*Histogram
+	rtt       map[MetricKey]*Histogram
+	limiter   map[MetricKey]*Histogram
+}
+
+// NewMemoryMetrics creates MemoryMetrics with histogram buckets in seconds.
+// Nil buckets use DefaultLatencyBuckets.
+func NewMemoryMetrics(buckets []float64) *MemoryMetrics {
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &MemoryMetrics{
+		buckets:   buckets,
+		sent:      make(map[MetricKey]uint64),
+		received:  make(map[MetricKey]uint64),
+		responses: make(map[MetricKey]uint64),
+		rebinds:   make(map[MetricKey]uint64),
+		window:    make(map[string]int),
+		latency:   make(map[MetricKey]*Histogram),
+		rtt:       make(map[MetricKey]*Histogram),
+		limiter:   make(map[MetricKey]*Histogram),
+	}
+}
+
+// PDUSent implements Metrics.
+func (m *MemoryMetrics) PDUSent(session string, id data.CommandIDType) {
+	m.count(m.sent, MetricKey{Session: session, CommandID: id})
+}
+
+// PDUReceived implements Metrics.
+func (m *MemoryMetrics) PDUReceived(session string, id data.CommandIDType) {
+	m.count(m.received, MetricKey{Session: session, CommandID: id})
+}
+
+// ResponseStatus implements Metrics.
+func (m *MemoryMetrics) ResponseStatus(session string, id data.CommandIDType, status data.CommandStatusType) {
+	m.count(m.responses, MetricKey{Session: session, CommandID: id, Status: status})
+}
+
+// ResponseLatency implements Metrics.
+func (m *MemoryMetrics) ResponseLatency(session string, id data.CommandIDType, latency time.Duration) {
+	m.observe(m.latency, MetricKey{Session: session, CommandID: id}, latency)
+}
+
+// WindowOccupancy implements Metrics.
+func (m *MemoryMetrics) WindowOccupancy(session string, occupied int) {
+	m.mu.Lock()
+	m.window[session] = occupied
+	m.mu.Unlock()
+}
+
+// RebindAttempt implements Metrics.
+func (m *MemoryMetrics) RebindAttempt(session string, err error) {
+	m.count(m.rebinds, MetricKey{Session: session, Success: err == nil})
+}
+
+// EnquireLinkRTT implements Metrics.
+func (m *MemoryMetrics) EnquireLinkRTT(session string, rtt time.Duration) {
+	m.observe(m.rtt, MetricKey{Session: session}, rtt)
+}
+
+// LimiterWait implements Metrics.
+func (m *MemoryMetrics) LimiterWait(session string, wait time.Duration) {
+	m.observe(m.limiter, MetricKey{Session: session}, wait)
+}
+
+// Snapshot returns copy of current metrics.
+func (m *MemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := MetricsSnapshot{
+		Sent:           copyCounters(m.sent),
+		Received:       copyCounters(m.received),
+		Responses:      copyCounters(m.responses),
+		Rebinds:        copyCounters(m.rebinds),
+		Window:         make(map[string]int, len(m.window)),
+		Latency:        copyHistograms(m.latency),
+		EnquireLinkRTT: copyHistograms(m.rtt),
+		LimiterWait:    copyHistograms(m.limiter),
+	}
+	for k, v := range m.window {
+		s.Window[k] = v
+	}
+	return s
+}
+
+func (m *MemoryMetrics) count(counters map[MetricKey]uint64, key MetricKey) {
+	m.mu.Lock()
+	counters[key]++
+	m.mu.Unlock()
+}
+
+func (m *MemoryMetrics) observe(histograms map[MetricKey]*Histogram, key MetricKey, d time.Duration) {
+	m.mu.Lock()
+	h, ok := histograms[key]
+	if !ok {
+		h = newHistogram(m.buckets)
+		histograms[key] = h
+	}
+	h.observe(d)
+	m.mu.Unlock()
+}
+
+func copyCounters(src map[MetricKey]uint64) map[MetricKey]uint64 {
+	dst := make(map[MetricKey]uint64, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+func copyHistograms(src map[MetricKey]*Histogram) map[MetricKey]Histogram {
+	dst := make(map[MetricKey]Histogram, len(src))
+	for k, v := range src {
+		dst[k] = v.clone()
+	}
+	return dst
+}
+
+// NopMetrics discards metrics, disabling instrumentation.
+type NopMetrics struct{}
+
+func (NopMetrics) PDUSent(string, data.CommandIDType)                                {}
+func (NopMetrics) PDUReceived(string, data.CommandIDType)                            {}
+func (NopMetrics) ResponseStatus(string, data.CommandIDType, data.CommandStatusType) {}
+func (NopMetrics) ResponseLatency(string, data.CommandIDType, time.Duration)         {}
+func (NopMetrics) WindowOccupancy(string, int)                                       {}
+func (NopMetrics) RebindAttempt(string, error)                                       {}
+func (NopMetrics) EnquireLinkRTT(string, time.Duration)                              {}
+func (NopMetrics) LimiterWait(string, time.Duration)                                 {}
+
+// metricsOf returns Settings.Metrics, discarding metrics if nil.
+// It is resolved once per transmitter, receiver and transceiver, not per PDU.
+func metricsOf(settings *Settings) Metrics {
+	if settings.Metrics != nil {
+		return settings.Metrics
+	}
+	return NopMetrics{}
+}
@@ -0,0 +1,110 @@
+package smpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestMemoryMetrics(t *testing.T) {
+	m := NewMemoryMetrics([]float64{1, 0.1})
+
+	m.PDUSent("s", data.SUBMIT_SM)
+	m.PDUSent("s", data.SUBMIT_SM)
+	m.PDUReceived("s", data.SUBMIT_SM_RESP)
+	m.ResponseStatus("s", data.SUBMIT_SM_RESP, data.ESME_RTHROTTLED)
+	m.ResponseLatency("s", data.SUBMIT_SM, 50*time.Millisecond)
+	m.ResponseLatency("s", data.SUBMIT_SM, 500*time.Millisecond)
+	m.ResponseLatency("s", data.SUBMIT_SM, 5*time.Second)
+	m.WindowOccupancy("s", 3)
+	m.RebindAttempt("s", errors.New("refused"))
+	m.RebindAttempt("s", nil)
+
+	s := m.Snapshot()
+	require.Equal(t, uint64(2), s.Sent[MetricKey{Session: "s", CommandID: data.SUBMIT_SM}])
+	require.Equal(t, uint64(1), s.Received[MetricKey{Session: "s", CommandID: data.SUBMIT_SM_RESP}])
+	require.Equal(t, uint64(1), s.Responses[MetricKey{Session: "s", CommandID: data.SUBMIT_SM_RESP, Status: data.ESME_RTHROTTLED}])
+	require.Equal(t, 3, s.Window["s"])
+	require.Equal(t, uint64(1), s.Rebinds[MetricKey{Session: "s"}])
+	require.Equal(t, uint64(1), s.Rebinds[MetricKey{Session: "s", Success: true}])
+
+	h := s.Latency[MetricKey{Session: "s", CommandID: data.SUBMIT_SM}]
+	require.Equal(t, []float64{0.1, 1}, h.Buckets)
+	require.Equal(t, []uint64{1, 2}, h.Counts)
+	require.Equal(t, uint64(3), h.Count)
+	require.InDelta(t, 5.55, h.Sum, 1e-9)
+
+	// snapshot is a copy
+	m.ResponseLatency("s", data.SUBMIT_SM, time.Millisecond)
+	require.Equal(t, []uint64{1, 2}, h.Counts)
+}
+
+func TestSessionMetrics(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	m := NewMemoryMetrics(nil)
+	session, err := NewSession(TRXConnector(NonTLSDialer, Auth{SMSC: smsc.Addr()}), Settings{
+		ReadTimeout: time.Second,
+		EnquireLink: 20 * time.Millisecond,
+		WindowSize:  2,
+		RateLimiter: rate.NewLimiter(rate.Inf, 1),
+		Metrics:     m,
+	}, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+
+	_, err = session.Transceiver().SubmitResp(context.Background(), newSubmitSM("esme"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(m.Snapshot().EnquireLinkRTT) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	id := session.ID
+	s := m.Snapshot()
+	require.Equal(t, uint64(1), s.Sent[MetricKey{Session: id, CommandID: data.SUBMIT_SM}])
+	require.Equal(t, uint64(1), s.Received[MetricKey{Session: id, CommandID: data.SUBMIT_SM_RESP}])
+	require.Equal(t, uint64(1), s.Responses[MetricKey{Session: id, CommandID: data.SUBMIT_SM_RESP, Status: data.ESME_ROK}])
+	require.Equal(t, uint64(1), s.Latency[MetricKey{Session: id, CommandID: data.SUBMIT_SM}].Count)
+	require.Equal(t, 0, s.Window[id])
+	require.NotZero(t, s.LimiterWait[MetricKey{Session: id}].Count)
+
+	smsc.CloseConnections()
+	require.Eventually(t, func() bool {
+		return m.Snapshot().Rebinds[MetricKey{Session: id, Success: true}] == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDefaultMetrics(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	session, err := NewSession(TRXConnector(NonTLSDialer, Auth{SMSC: smsc.Addr()}), Settings{
+		ReadTimeout: time.Second,
+	}, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+
+	m, ok := session.Metrics().(*MemoryMetrics)
+	require.True(t, ok)
+
+	_, err = session.Transceiver().SubmitResp(context.Background(), newSubmitSM("esme"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), m.Snapshot().Sent[MetricKey{Session: session.ID, CommandID: data.SUBMIT_SM}])
+
+	manager, err := NewManager(Setting{})
+	require.NoError(t, err)
+	require.IsType(t, &MemoryMetrics{}, manager.Metrics())
+}
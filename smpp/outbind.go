@@ -0,0 +1,275 @@
+package smpp
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+var (
+	// ErrOutbindCredentials indicates outbind carries unexpected system_id or password.
+	ErrOutbindCredentials = fmt.Errorf("outbind: invalid system_id or password")
+
+	// ErrOutbindExpected indicates SMSC sent other PDU than outbind on incoming connection.
+	ErrOutbindExpected = fmt.Errorf("outbind: first PDU is not outbind")
+
+	// ErrOutbindListenerClosed indicates OutbindListener is closed.
+	ErrOutbindListenerClosed = fmt.Errorf("outbind: listener closed")
+
+	// ErrOutbindTimeout indicates no outbind is bound within OutbindSettings.WaitTimeout.
+	ErrOutbindTimeout = fmt.Errorf("outbind: timeout waiting for outbind")
+)
+
+// OutbindSettings for OutbindListener.
+type OutbindSettings struct {
+	// SystemID and Password expected within outbind from SMSC.
+	// Empty SystemID accepts outbind from any SMSC.
+	SystemID string
+	Password string
+
+	// Auth is used for bind_receiver issued on outbound connection. Auth.SMSC is ignored.
+	Auth Auth
+
+	// BindTimeout limits duration from accepting connection until bind_resp is received.
+	// Zero means no limit.
+	BindTimeout time.Duration
+
+	// WaitTimeout limits Accept (and Connect of Connector) waiting for next bound connection.
+	// Zero means waiting until listener is closed.
+	WaitTimeout time.Duration
+
+	// OnError notifies incoming connection which failed outbind or binding.
+	OnError func(remote net.Addr, err error)
+}
+
+// OutbindListener accepts connections initiated by SMSC with outbind,
+// and binds them as receiver.
+type OutbindListener struct {
+	settings OutbindSettings
+	listener net.Listener
+
+	conns chan outbound
+	done  chan struct{}
+	once  sync.Once
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	pending map[net.Conn]struct{} // connections not yet accepted
+}
+
+// ListenOutbind announces on TCP address for outbind from SMSC.
+func ListenOutbind(addr string, settings OutbindSettings) (*OutbindListener, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewOutbindListener(l, settings), nil
+}
+
+// NewOutbindListener accepts outbind from SMSC over given listener.
+func NewOutbindListener(l net.Listener, settings OutbindSettings) *OutbindListener {
+	o := &OutbindListener{
+		settings: settings,
+		listener: l,
+		conns:    make(chan outbound),
+		done:     make(chan struct{}),
+		pending:  make(map[net.Conn]struct{}),
+	}
+
+	o.wg.Add(1)
+	go o.accept()
+
+	return o
+}
+
+// Addr returns listening address.
+func (o *OutbindListener) Addr() net.Addr {
+	return o.listener.Addr()
+}
+
+// Accept waits for next connection outbound by SMSC and binds it as receiver.
+func (o *OutbindListener) Accept() (*Connection, error) {
+	return o.AcceptContext(context.Background())
+}
+
+// AcceptContext is like Accept, but stops waiting once ctx is done.
+// The ctx applies to binding as well, like for Connector.ConnectContext.
+func (o *OutbindListener) AcceptContext(ctx context.Context) (*Connection, error) {
+	var timeout <-chan time.Time
+	if o.settings.WaitTimeout > 0 {
+		timer := time.NewTimer(o.settings.WaitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		select {
+		case ob := <-o.conns:
+			conn, err := o.bind(ctx, ob)
+			if err == nil {
+				return conn, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if o.settings.OnError != nil {
+				o.settings.OnError(ob.conn.RemoteAddr(), err)
+			}
+
+		case <-o.done:
+			return nil, ErrOutbindListenerClosed
+
+		case <-timeout:
+			return nil, ErrOutbindTimeout
+
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Connector returns Connector which waits for next outbind instead of dialing SMSC.
+func (o *OutbindListener) Connector() Connector {
+	return outbindConnector{o}
+}
+
+// NewSession waits for outbind and creates receiver session on it.
+//
+// Session rebinds by waiting for SMSC to outbind again, see NewSession.
+func (o *OutbindListener) NewSession(settings Settings, rebindingInterval time.Duration) (*Session, error) {
+	return NewSession(o.Connector(), settings, rebindingInterval)
+}
+
+// Close stops listening. Connections waiting to be accepted are closed,
+// while connections already accepted are not affected.
+func (o *OutbindListener) Close() (err error) {
+	o.once.Do(func() {
+		close(o.done)
+		err = o.listener.Close()
+
+		o.mu.Lock()
+		for nc := range o.pending {
+			_ = nc.Close()
+		}
+		o.mu.Unlock()
+
+		o.wg.Wait()
+	})
+	return
+}
+
+func (o *OutbindListener) accept() {
+	defer o.wg.Done()
+
+	for {
+		nc, err := o.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		o.mu.Lock()
+		o.pending[nc] = struct{}{}
+		o.mu.Unlock()
+
+		o.wg.Add(1)
+		go func() {
+			defer func() {
+				o.mu.Lock()
+				delete(o.pending, nc)
+				o.mu.Unlock()
+
+				o.wg.Done()
+			}()
+			o.handle(nc)
+		}()
+	}
+}
+
+// outbound is connection whose outbind is validated, waiting to be bound by AcceptContext.
+type outbound struct {
+	conn     net.Conn
+	accepted time.Time
+}
+
+func (o *OutbindListener) handle(nc net.Conn) {
+	ob := outbound{conn: nc, accepted: time.Now()}
+	if err := o.outbind(ob); err != nil {
+		if o.settings.OnError != nil {
+			o.settings.OnError(nc.RemoteAddr(), err)
+		}
+		return
+	}
+
+	select {
+	case o.conns <- ob:
+	case <-o.done:
+		_ = nc.Close()
+	}
+}
+
+// outbind validates outbind from SMSC.
+func (o *OutbindListener) outbind(ob outbound) (err error) {
+	nc := ob.conn
+	if o.settings.BindTimeout > 0 {
+		if err = nc.SetDeadline(ob.accepted.Add(o.settings.BindTimeout)); err != nil {
+			_ = nc.Close()
+			return
+		}
+	}
+
+	p, err := pdu.Parse(nc)
+	if err != nil {
+		_ = nc.Close()
+		return
+	}
+
+	outbind, ok := p.(*pdu.Outbind)
+	if !ok {
+		_ = nc.Close()
+		return ErrOutbindExpected
+	}
+
+	if !o.valid(outbind) {
+		_ = nc.Close()
+		return ErrOutbindCredentials
+	}
+	return
+}
+
+// bind issues bind_receiver on outbound connection.
+func (o *OutbindListener) bind(ctx context.Context, ob outbound) (*Connection, error) {
+	if o.settings.BindTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, ob.accepted.Add(o.settings.BindTimeout))
+		defer cancel()
+	}
+
+	// deadline of outbind is replaced by the one of ctx, and cleared once bound
+	return bind(ctx, ob.conn, newBindRequest(o.settings.Auth, pdu.Receiver))
+}
+
+func (o *OutbindListener) valid(outbind *pdu.Outbind) bool {
+	if o.settings.SystemID == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(outbind.SystemID), []byte(o.settings.SystemID)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(outbind.Password), []byte(o.settings.Password)) == 1
+}
+
+type outbindConnector struct {
+	listener *OutbindListener
+}
+
+func (c outbindConnector) Connect() (*Connection, error) {
+	return c.listener.Accept()
+}
+
+func (c outbindConnector) ConnectContext(ctx context.Context) (*Connection, error) {
+	return c.listener.AcceptContext(ctx)
+}
@@ -0,0 +1,129 @@
+package smpp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutbindListener(t *testing.T) {
+	newListener := func(t *testing.T, settings OutbindSettings) *OutbindListener {
+		settings.SystemID = "smsc"
+		settings.Password = "pwd"
+		settings.Auth = Auth{SystemID: "esme", Password: "secret"}
+
+		l, err := ListenOutbind("127.0.0.1:0", settings)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = l.Close()
+		})
+		return l
+	}
+
+	t.Run("session with rebind", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		l := newListener(t, OutbindSettings{BindTimeout: time.Second})
+		require.NoError(t, smsc.Outbind(l.Addr().String(), "smsc", "pwd"))
+
+		var events eventRecorder
+		received := make(chan pdu.PDU, 10)
+		session, err := l.NewSession(Settings{
+			ReadTimeout:   time.Second,
+			OnStateChange: events.record,
+			OnPDU: func(p pdu.PDU, _ bool) {
+				received <- p
+			},
+		}, 50*time.Millisecond)
+		require.NoError(t, err)
+		defer func() {
+			_ = session.Close()
+		}()
+		require.Equal(t, []SessionState{StateConnecting, StateBinding, StateBound}, events.states())
+
+		binds := smsc.ReceivedOf(data.BIND_RECEIVER)
+		require.Len(t, binds, 1)
+		require.Equal(t, "esme", binds[0].(*pdu.BindRequest).SystemID)
+		require.Equal(t, "secret", binds[0].(*pdu.BindRequest).Password)
+		require.Equal(t, smsctest.DefaultSystemID, session.Receiver().SystemID())
+
+		deliver := func() {
+			require.NoError(t, smsc.Send(pdu.NewDeliverSM()))
+			select {
+			case p := <-received:
+				require.IsType(t, &pdu.DeliverSM{}, p)
+			case <-time.After(time.Second):
+				t.Fatal("deliver_sm is not received")
+			}
+		}
+		deliver()
+
+		// SMSC drops connection, then outbinds again
+		rx := session.Receiver()
+		smsc.CloseConnections()
+		require.NoError(t, smsc.Outbind(l.Addr().String(), "smsc", "pwd"))
+
+		require.Eventually(t, func() bool {
+			return session.Receiver() != rx && smsc.Bound() == 1
+		}, time.Second, 10*time.Millisecond)
+		require.Len(t, smsc.ReceivedOf(data.BIND_RECEIVER), 2)
+		deliver()
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		errs := make(chan error, 1)
+		l := newListener(t, OutbindSettings{
+			WaitTimeout: 100 * time.Millisecond,
+			OnError: func(_ net.Addr, err error) {
+				errs <- err
+			},
+		})
+		require.NoError(t, smsc.Outbind(l.Addr().String(), "smsc", "wrong"))
+
+		require.ErrorIs(t, <-errs, ErrOutbindCredentials)
+		_, err := l.Accept()
+		require.ErrorIs(t, err, ErrOutbindTimeout)
+		require.Empty(t, smsc.ReceivedOf(data.BIND_RECEIVER))
+	})
+
+	t.Run("context aborts binding", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		l := newListener(t, OutbindSettings{})
+		smsc.Stall()
+		require.NoError(t, smsc.Outbind(l.Addr().String(), "smsc", "pwd"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := l.Connector().ConnectContext(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Len(t, smsc.ReceivedOf(data.BIND_RECEIVER), 1)
+
+		// aborted connection is closed instead of being bound later
+		smsc.Resume()
+		require.Never(t, func() bool {
+			return smsc.Bound() > 0
+		}, 100*time.Millisecond, 10*time.Millisecond)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		l := newListener(t, OutbindSettings{})
+		require.NoError(t, l.Close())
+
+		_, err := l.Connector().Connect()
+		require.ErrorIs(t, err, ErrOutbindListenerClosed)
+	})
+}
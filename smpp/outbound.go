@@ -0,0 +1,270 @@
+package smpp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+// DefaultOutboundBufferSize is used if OutboundBufferSettings.Size is not positive.
+const DefaultOutboundBufferSize = 1000
+
+var (
+	// ErrOutboundBufferFull indicates PDU is rejected or evicted by OverflowPolicy.
+	ErrOutboundBufferFull = fmt.Errorf("outbound buffer is full")
+
+	// ErrUnacknowledged indicates request is left without response when its connection is closed.
+	ErrUnacknowledged = fmt.Errorf("request is not acknowledged before connection closed")
+)
+
+// OverflowPolicy decides what happens to PDU submitted to full outbound buffer.
+type OverflowPolicy int
+
+const (
+	// OverflowReject fails submitting with ErrOutboundBufferFull.
+	OverflowReject OverflowPolicy = iota
+
+	// OverflowDropOldest evicts the oldest queued PDU, reported to OnDrop with ErrOutboundBufferFull.
+	OverflowDropOldest
+
+	// OverflowBlock waits for free space until context of submitting is done.
+	OverflowBlock
+)
+
+// InFlightPolicy decides what happens to requests left without response when connection is lost.
+type InFlightPolicy int
+
+const (
+	// InFlightReport reports requests to OnDrop with ErrUnacknowledged.
+	InFlightReport InFlightPolicy = iota
+
+	// InFlightResend queues requests again, ahead of other queued PDUs.
+	// SMSC might have accepted some of them, so they could be delivered twice.
+	InFlightResend
+)
+
+// OutboundBufferSettings for store-and-forward queue of Session.
+type OutboundBufferSettings struct {
+	// Size is maximum number of queued PDUs. Requests queued again by InFlightResend are not limited.
+	Size int
+
+	// Overflow is policy applied when buffer is full.
+	Overflow OverflowPolicy
+
+	// InFlight is policy applied to requests without response when connection is lost.
+	InFlight InFlightPolicy
+
+	// OnDrop notifies PDU which is given up along with reason, e.g.
+	// ErrOutboundBufferFull, ErrUnacknowledged or ErrConnectionClosing if session is closed.
+	OnDrop PDUErrorCallback
+}
+
+// outboundBuffer holds PDUs submitted via Session, forwarding them to bound transceiver.
+type outboundBuffer struct {
+	settings OutboundBufferSettings
+	size     int
+
+	mu     sync.Mutex
+	resend []pdu.PDU // lost requests, forwarded first
+	queue  []pdu.PDU
+	closed bool
+
+	stopped    bool // no more PDU is accepted
+	forwarding bool // popped PDU is being forwarded
+
+	notEmpty  chan struct{}
+	notFull   chan struct{}
+	forwarded chan struct{}
+	bound     chan struct{} // closed and replaced once session is rebound
+}
+
+func newOutboundBuffer(settings OutboundBufferSettings) *outboundBuffer {
+	b := &outboundBuffer{
+		settings:  settings,
+		size:      settings.Size,
+		notEmpty:  make(chan struct{}, 1),
+		notFull:   make(chan struct{}, 1),
+		forwarded: make(chan struct{}, 1),
+		bound:     make(chan struct{}),
+	}
+	if b.size <= 0 {
+		b.size = DefaultOutboundBufferSize
+	}
+	return b
+}
+
+// push PDU at the end of queue, applying overflow policy.
+func (b *outboundBuffer) push(ctx context.Context, p pdu.PDU) error {
+	for {
+		b.mu.Lock()
+		if b.closed || b.stopped {
+			b.mu.Unlock()
+			return ErrConnectionClosing
+		}
+
+		if len(b.queue) < b.size {
+			b.queue = append(b.queue, p)
+			free := len(b.queue) < b.size
+			b.mu.Unlock()
+
+			signal(b.notEmpty)
+			if free {
+				// wake up other blocked producer
+				signal(b.notFull)
+			}
+			return nil
+		}
+
+		switch b.settings.Overflow {
+		case OverflowDropOldest:
+			oldest := b.queue[0]
+			b.queue = append(b.queue[1:], p)
+			b.mu.Unlock()
+
+			b.drop(oldest, ErrOutboundBufferFull)
+			signal(b.notEmpty)
+			return nil
+
+		case OverflowBlock:
+			b.mu.Unlock()
+
+			select {
+			case <-b.notFull:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+		default:
+			b.mu.Unlock()
+			return ErrOutboundBufferFull
+		}
+	}
+}
+
+// pop next PDU, waiting until there is one or ctx is done.
+func (b *outboundBuffer) pop(ctx context.Context) (p pdu.PDU, ok bool) {
+	for {
+		b.mu.Lock()
+		switch {
+		case len(b.resend) > 0:
+			p, b.resend = b.resend[0], b.resend[1:]
+			b.forwarding = true
+			b.mu.Unlock()
+			return p, true
+
+		case len(b.queue) > 0:
+			p, b.queue = b.queue[0], b.queue[1:]
+			b.forwarding = true
+			b.mu.Unlock()
+			signal(b.notFull)
+			return p, true
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-b.notEmpty:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// done marks popped PDU as forwarded or dropped.
+func (b *outboundBuffer) done() {
+	b.mu.Lock()
+	b.forwarding = false
+	b.mu.Unlock()
+
+	signal(b.forwarded)
+}
+
+// stop accepting PDUs, producers blocked by OverflowBlock give up.
+func (b *outboundBuffer) stop() {
+	b.mu.Lock()
+	b.stopped = true
+	b.mu.Unlock()
+
+	signal(b.notFull)
+}
+
+// flush waits until all queued PDUs are forwarded or ctx is done.
+func (b *outboundBuffer) flush(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		empty := len(b.resend) == 0 && len(b.queue) == 0 && !b.forwarding
+		b.mu.Unlock()
+
+		if empty {
+			return nil
+		}
+
+		select {
+		case <-b.forwarded:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// lost handles request left without response, according to in-flight policy.
+func (b *outboundBuffer) lost(p pdu.PDU) {
+	if b.settings.InFlight == InFlightResend {
+		b.mu.Lock()
+		if !b.closed {
+			b.resend = append(b.resend, p)
+			b.mu.Unlock()
+
+			signal(b.notEmpty)
+			return
+		}
+		b.mu.Unlock()
+	}
+
+	b.drop(p, ErrUnacknowledged)
+}
+
+// boundSignal returns channel which is closed once session is rebound.
+func (b *outboundBuffer) boundSignal() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.bound
+}
+
+// rebound notifies forwarder waiting for new transceiver.
+func (b *outboundBuffer) rebound() {
+	b.mu.Lock()
+	close(b.bound)
+	b.bound = make(chan struct{})
+	b.mu.Unlock()
+}
+
+// close buffer, reporting and returning PDUs which are never forwarded.
+func (b *outboundBuffer) close() (remaining []pdu.PDU) {
+	b.mu.Lock()
+	b.closed = true
+	remaining = append(b.resend, b.queue...)
+	b.resend, b.queue = nil, nil
+	b.mu.Unlock()
+
+	signal(b.notFull)
+	for _, p := range remaining {
+		b.drop(p, ErrConnectionClosing)
+	}
+	return
+}
+
+func (b *outboundBuffer) drop(p pdu.PDU, err error) {
+	if b.settings.OnDrop != nil {
+		b.settings.OnDrop(p, err)
+	}
+}
+
+// signal non-blocking notifies waiter of channel.
+func signal(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
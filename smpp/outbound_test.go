@@ -0,0 +1,221 @@
+package smpp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboundBufferOverflow(t *testing.T) {
+	newBuffer := func(overflow OverflowPolicy, dropped *[]pdu.PDU) *outboundBuffer {
+		return newOutboundBuffer(OutboundBufferSettings{
+			Size:     2,
+			Overflow: overflow,
+			OnDrop: func(p pdu.PDU, err error) {
+				require.ErrorIs(t, err, ErrOutboundBufferFull)
+				*dropped = append(*dropped, p)
+			},
+		})
+	}
+	ctx := context.Background()
+	p1, p2, p3 := pdu.NewSubmitSM(), pdu.NewSubmitSM(), pdu.NewSubmitSM()
+
+	t.Run("reject", func(t *testing.T) {
+		var dropped []pdu.PDU
+		b := newBuffer(OverflowReject, &dropped)
+		require.NoError(t, b.push(ctx, p1))
+		require.NoError(t, b.push(ctx, p2))
+		require.ErrorIs(t, b.push(ctx, p3), ErrOutboundBufferFull)
+		require.Empty(t, dropped)
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		var dropped []pdu.PDU
+		b := newBuffer(OverflowDropOldest, &dropped)
+		require.NoError(t, b.push(ctx, p1))
+		require.NoError(t, b.push(ctx, p2))
+		require.NoError(t, b.push(ctx, p3))
+		require.Equal(t, []pdu.PDU{p1}, dropped)
+
+		p, _ := b.pop(ctx)
+		require.Same(t, p2, p)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		var dropped []pdu.PDU
+		b := newBuffer(OverflowBlock, &dropped)
+		require.NoError(t, b.push(ctx, p1))
+		require.NoError(t, b.push(ctx, p2))
+
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, b.push(timeout, p3), context.DeadlineExceeded)
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			_, _ = b.pop(ctx)
+		}()
+		require.NoError(t, b.push(ctx, p3))
+	})
+
+	t.Run("resend first", func(t *testing.T) {
+		b := newOutboundBuffer(OutboundBufferSettings{InFlight: InFlightResend})
+		require.NoError(t, b.push(ctx, p1))
+		b.lost(p2)
+
+		p, _ := b.pop(ctx)
+		require.Same(t, p2, p)
+		p, _ = b.pop(ctx)
+		require.Same(t, p1, p)
+	})
+}
+
+func TestSessionOutboundBuffer(t *testing.T) {
+	type drop struct {
+		p   pdu.PDU
+		err error
+	}
+
+	newSession := func(t *testing.T, smsc *smsctest.Server, policy InFlightPolicy) (*Session, <-chan pdu.PDU, <-chan drop) {
+		responses := make(chan pdu.PDU, 10)
+		drops := make(chan drop, 10)
+
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout: time.Second,
+			OnPDU: func(p pdu.PDU, _ bool) {
+				if resp, ok := p.(*pdu.SubmitSMResp); ok {
+					responses <- resp
+				}
+			},
+			OutboundBuffer: &OutboundBufferSettings{
+				Size:     10,
+				InFlight: policy,
+				OnDrop: func(p pdu.PDU, err error) {
+					drops <- drop{p: p, err: err}
+				},
+			},
+		}, 50*time.Millisecond)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = session.Close()
+		})
+		return session, responses, drops
+	}
+
+	// submits requests which are never responded, then drops connection.
+	// Stalled SMSC reads no further than the first request.
+	loseInFlight := func(t *testing.T, smsc *smsctest.Server, session *Session) []int32 {
+		smsc.Stall()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, session.Submit(newSubmitSM("esme")))
+		}
+		require.Eventually(t, func() bool {
+			return len(smsc.ReceivedOf(data.SUBMIT_SM)) == 1
+		}, time.Second, 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+
+		var sequences []int32
+		for _, p := range smsc.ReceivedOf(data.SUBMIT_SM) {
+			sequences = append(sequences, p.GetSequenceNumber())
+		}
+
+		smsc.CloseConnections()
+		smsc.Resume()
+		return sequences
+	}
+
+	t.Run("resend", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		session, responses, drops := newSession(t, smsc, InFlightResend)
+		sequences := loseInFlight(t, smsc, session)
+
+		// queued while rebinding
+		for i := 0; i < 2; i++ {
+			require.NoError(t, session.Submit(newSubmitSM("esme")))
+		}
+
+		for i := 0; i < 5; i++ {
+			select {
+			case <-responses:
+			case d := <-drops:
+				t.Fatalf("dropped: %v", d.err)
+			case <-time.After(2 * time.Second):
+				t.Fatal("response is not received")
+			}
+		}
+
+		submits := smsc.ReceivedOf(data.SUBMIT_SM)
+		require.Len(t, submits, 6)
+		for _, p := range submits[1:] {
+			require.NotContains(t, sequences, p.GetSequenceNumber())
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		session, _, drops := newSession(t, smsc, InFlightReport)
+		loseInFlight(t, smsc, session)
+
+		for i := 0; i < 3; i++ {
+			select {
+			case d := <-drops:
+				require.ErrorIs(t, d.err, ErrUnacknowledged)
+			case <-time.After(time.Second):
+				t.Fatal("in-flight request is not reported")
+			}
+		}
+
+		require.Eventually(t, func() bool {
+			return smsc.Bound() == 1
+		}, time.Second, 10*time.Millisecond)
+		require.Len(t, smsc.ReceivedOf(data.SUBMIT_SM), 1)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		var mu sync.Mutex
+		var dropped []error
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout: time.Second,
+			OutboundBuffer: &OutboundBufferSettings{
+				OnDrop: func(_ pdu.PDU, err error) {
+					mu.Lock()
+					dropped = append(dropped, err)
+					mu.Unlock()
+				},
+			},
+			// lost connection is never rebound within the test
+			RebindPolicy: &BackoffRebindPolicy{InitialInterval: time.Hour},
+		}, 0)
+		require.NoError(t, err)
+
+		smsc.CloseConnections()
+		require.Eventually(t, func() bool {
+			return session.bound().ctx.Err() != nil
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, session.Submit(newSubmitSM("esme")))
+
+		require.NoError(t, session.Close())
+		require.ErrorIs(t, session.Submit(newSubmitSM("esme")), ErrConnectionClosing)
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.NotEmpty(t, dropped)
+		for _, err := range dropped {
+			require.ErrorIs(t, err, ErrConnectionClosing)
+		}
+	})
+}
@@ -37,6 +37,15 @@ func TestBindResponse(t *testing.T) {
 			"0000001f80000002000000000000000d73797374656d5f69645f66616b6500",
 			data.BIND_TRANSMITTER_RESP,
 		)
+
+		v.SystemID = ""
+		v.CommandStatus = data.ESME_RINVPASWD
+
+		validate(t,
+			v,
+			"00000010800000020000000e0000000d",
+			data.BIND_TRANSMITTER_RESP,
+		)
 	})
 
 	t.Run("transceiver", func(t *testing.T) {
@@ -0,0 +1,67 @@
+package pdu
+
+import (
+	"encoding/binary"
+
+	"github.com/sujit-baniya/protocol/smpp/errors"
+)
+
+// BroadcastArea is value of broadcast_area_identifier TLV, which identifies target area of a cell broadcast.
+type BroadcastArea struct {
+	// Format of area details, e.g. data.BROADCAST_AREA_FORMAT_ALIAS.
+	Format byte
+
+	// Details of area, such as its name or coordinates.
+	Details []byte
+}
+
+// Field returns broadcast_area_identifier TLV.
+func (a BroadcastArea) Field() Field {
+	return Field{
+		Tag:  TagBroadcastAreaIdentifier,
+		Data: append([]byte{a.Format}, a.Details...),
+	}
+}
+
+func parseBroadcastArea(f Field) (a BroadcastArea, err error) {
+	if len(f.Data) == 0 {
+		err = errors.ErrInvalidPDU
+		return
+	}
+
+	a.Format = f.Data[0]
+	if len(f.Data) > 1 {
+		a.Details = f.Data[1:]
+	}
+	return
+}
+
+func marshalBroadcastAreas(b *ByteBuffer, areas []BroadcastArea) {
+	for _, area := range areas {
+		field := area.Field()
+		field.Marshal(b)
+	}
+}
+
+// NewBroadcastContentType returns broadcast_content_type TLV.
+// Network type is e.g. data.BROADCAST_NETWORK_GSM, service type is the broadcast service offered.
+func NewBroadcastContentType(networkType byte, serviceType uint16) Field {
+	v := []byte{networkType, 0, 0}
+	binary.BigEndian.PutUint16(v[1:], serviceType)
+	return Field{Tag: TagBroadcastContentType, Data: v}
+}
+
+// NewBroadcastRepNum returns broadcast_rep_num TLV, number of repeated broadcasts requested.
+func NewBroadcastRepNum(n uint16) Field {
+	v := make([]byte, 2)
+	binary.BigEndian.PutUint16(v, n)
+	return Field{Tag: TagBroadcastRepNum, Data: v}
+}
+
+// NewBroadcastFrequencyInterval returns broadcast_frequency_interval TLV.
+// Unit is e.g. data.BROADCAST_FREQ_MINUTES.
+func NewBroadcastFrequencyInterval(unit byte, interval uint16) Field {
+	v := []byte{unit, 0, 0}
+	binary.BigEndian.PutUint16(v[1:], interval)
+	return Field{Tag: TagBroadcastFrequencyInterval, Data: v}
+}
@@ -0,0 +1,111 @@
+package pdu
+
+import (
+	"github.com/sujit-baniya/protocol/smpp/data"
+)
+
+// BroadcastSM PDU (SMPP v5.0) is issued by the ESME to submit a message to the SMSC for broadcast
+// to a specified geographical area or set of geographical areas.
+//
+// Broadcast_content_type, broadcast_rep_num and broadcast_frequency_interval TLVs are mandatory,
+// see NewBroadcastContentType, NewBroadcastRepNum and NewBroadcastFrequencyInterval.
+type BroadcastSM struct {
+	base
+	ServiceType          string
+	SourceAddr           Address
+	MessageID            string
+	PriorityFlag         byte
+	ScheduleDeliveryTime string
+	ValidityPeriod       string
+	ReplaceIfPresentFlag byte
+	DataCoding           byte
+	SmDefaultMsgID       byte
+
+	// Areas are broadcast_area_identifier TLVs, at least one is required.
+	Areas []BroadcastArea
+}
+
+// NewBroadcastSM returns BroadcastSM PDU.
+func NewBroadcastSM() PDU {
+	c := &BroadcastSM{
+		base:                 newBase(),
+		ServiceType:          data.DFLT_SRVTYPE,
+		SourceAddr:           NewAddress(),
+		MessageID:            data.DFLT_MSGID,
+		PriorityFlag:         data.DFLT_PRIORITY_FLAG,
+		ScheduleDeliveryTime: data.DFLT_SCHEDULE,
+		ValidityPeriod:       data.DFLT_VALIDITY,
+		ReplaceIfPresentFlag: data.DFTL_REPLACE_IFP,
+		DataCoding:           data.DFLT_DATA_CODING,
+		SmDefaultMsgID:       data.DFLT_DFLTMSGID,
+	}
+	c.CommandID = data.BROADCAST_SM
+	return c
+}
+
+// CanResponse implements PDU interface.
+func (c *BroadcastSM) CanResponse() bool {
+	return true
+}
+
+// GetResponse implements PDU interface.
+func (c *BroadcastSM) GetResponse() PDU {
+	return NewBroadcastSMRespFromReq(c)
+}
+
+// Marshal implements PDU interface.
+func (c *BroadcastSM) Marshal(b *ByteBuffer) {
+	c.base.marshal(b, func(b *ByteBuffer) {
+		b.Grow(len(c.ServiceType) + len(c.MessageID) + len(c.ScheduleDeliveryTime) + len(c.ValidityPeriod) + 8)
+
+		_ = b.WriteCString(c.ServiceType)
+		c.SourceAddr.Marshal(b)
+		_ = b.WriteCString(c.MessageID)
+		_ = b.WriteByte(c.PriorityFlag)
+		_ = b.WriteCString(c.ScheduleDeliveryTime)
+		_ = b.WriteCString(c.ValidityPeriod)
+		_ = b.WriteByte(c.ReplaceIfPresentFlag)
+		_ = b.WriteByte(c.DataCoding)
+		_ = b.WriteByte(c.SmDefaultMsgID)
+		marshalBroadcastAreas(b, c.Areas)
+	})
+}
+
+// Unmarshal implements PDU interface.
+func (c *BroadcastSM) Unmarshal(b *ByteBuffer) error {
+	return c.base.unmarshal(b, func(b *ByteBuffer) (err error) {
+		start := b.Len()
+		if c.ServiceType, err = b.ReadCString(); err == nil {
+			if err = c.SourceAddr.Unmarshal(b); err == nil {
+				if c.MessageID, err = b.ReadCString(); err == nil {
+					if c.PriorityFlag, err = b.ReadByte(); err == nil {
+						if c.ScheduleDeliveryTime, err = b.ReadCString(); err == nil {
+							if c.ValidityPeriod, err = b.ReadCString(); err == nil {
+								if c.ReplaceIfPresentFlag, err = b.ReadByte(); err == nil {
+									if c.DataCoding, err = b.ReadByte(); err == nil {
+										if c.SmDefaultMsgID, err = b.ReadByte(); err == nil {
+											err = c.unmarshalRepeatedParams(b, start-b.Len(), c.takeArea)
+										}
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+		return
+	})
+}
+
+func (c *BroadcastSM) takeArea(f Field) (bool, error) {
+	if f.Tag != TagBroadcastAreaIdentifier {
+		return false, nil
+	}
+
+	area, err := parseBroadcastArea(f)
+	if err == nil {
+		c.Areas = append(c.Areas, area)
+	}
+	return true, err
+}
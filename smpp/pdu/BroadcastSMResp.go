@@ -0,0 +1,82 @@
+package pdu
+
+import (
+	"github.com/sujit-baniya/protocol/smpp/data"
+)
+
+// BroadcastSMResp PDU.
+type BroadcastSMResp struct {
+	base
+	MessageID string
+
+	// FailedAreas are failed_broadcast_area_identifier TLVs, reported by SMSC when broadcast
+	// to some areas is rejected.
+	FailedAreas []BroadcastArea
+}
+
+// NewBroadcastSMResp returns new BroadcastSMResp.
+func NewBroadcastSMResp() PDU {
+	c := &BroadcastSMResp{
+		base:      newBase(),
+		MessageID: data.DFLT_MSGID,
+	}
+	c.CommandID = data.BROADCAST_SM_RESP
+	return c
+}
+
+// NewBroadcastSMRespFromReq returns new BroadcastSMResp.
+func NewBroadcastSMRespFromReq(req *BroadcastSM) PDU {
+	c := NewBroadcastSMResp().(*BroadcastSMResp)
+	if req != nil {
+		c.SequenceNumber = req.SequenceNumber
+	}
+	return c
+}
+
+// CanResponse implements PDU interface.
+func (c *BroadcastSMResp) CanResponse() bool {
+	return false
+}
+
+// GetResponse implements PDU interface.
+func (c *BroadcastSMResp) GetResponse() PDU {
+	return nil
+}
+
+// Marshal implements PDU interface.
+func (c *BroadcastSMResp) Marshal(b *ByteBuffer) {
+	c.base.marshal(b, func(b *ByteBuffer) {
+		b.Grow(len(c.MessageID) + 1)
+
+		_ = b.WriteCString(c.MessageID)
+		marshalBroadcastAreas(b, c.FailedAreas)
+	})
+}
+
+// Unmarshal implements PDU interface.
+func (c *BroadcastSMResp) Unmarshal(b *ByteBuffer) error {
+	return c.base.unmarshal(b, func(b *ByteBuffer) (err error) {
+		// body might be omitted if command status is not ok
+		if int(c.CommandLength) == data.PDU_HEADER_SIZE {
+			return
+		}
+
+		start := b.Len()
+		if c.MessageID, err = b.ReadCString(); err == nil {
+			err = c.unmarshalRepeatedParams(b, start-b.Len(), c.takeFailedArea)
+		}
+		return
+	})
+}
+
+func (c *BroadcastSMResp) takeFailedArea(f Field) (bool, error) {
+	if f.Tag != TagBroadcastAreaIdentifier {
+		return false, nil
+	}
+
+	area, err := parseBroadcastArea(f)
+	if err == nil {
+		c.FailedAreas = append(c.FailedAreas, area)
+	}
+	return true, err
+}
@@ -0,0 +1,33 @@
+package pdu
+
+import (
+	"testing"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcastSMResp(t *testing.T) {
+	req := NewBroadcastSM().(*BroadcastSM)
+	req.SequenceNumber = 13
+
+	v := NewBroadcastSMRespFromReq(req).(*BroadcastSMResp)
+	require.False(t, v.CanResponse())
+	require.Nil(t, v.GetResponse())
+
+	v.MessageID = "foo"
+	v.FailedAreas = []BroadcastArea{{Format: data.BROADCAST_AREA_FORMAT_ALIAS, Details: []byte("no")}}
+
+	validate(t,
+		v,
+		"0000001b80000111000000000000000d666f6f0006060003006e6f",
+		data.BROADCAST_SM_RESP,
+	)
+
+	// body is omitted by SMSC
+	p, err := Parse(NewBuffer(fromHex("0000001080000111000001060000000d")))
+	require.Nil(t, err)
+	require.Equal(t, data.ESME_RBCASTFAIL, p.GetHeader().CommandStatus)
+	require.Empty(t, p.(*BroadcastSMResp).MessageID)
+}
@@ -0,0 +1,53 @@
+package pdu
+
+import (
+	"testing"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcastSM(t *testing.T) {
+	v := NewBroadcastSM().(*BroadcastSM)
+	require.True(t, v.CanResponse())
+	v.SequenceNumber = 13
+
+	validate(t,
+		v.GetResponse(),
+		"0000001180000111000000000000000d00",
+		data.BROADCAST_SM_RESP,
+	)
+
+	v.ServiceType = "abc"
+	_ = v.SourceAddr.SetAddress("Alicer")
+	v.SourceAddr.SetTon(28)
+	v.SourceAddr.SetNpi(29)
+	v.PriorityFlag = 1
+	v.DataCoding = 0x08
+	v.Areas = []BroadcastArea{
+		{Format: data.BROADCAST_AREA_FORMAT_ALIAS, Details: []byte("north")},
+		{Format: data.BROADCAST_AREA_FORMAT_ALIAS, Details: []byte("south")},
+	}
+	v.RegisterOptionalParam(NewBroadcastRepNum(3))
+
+	validate(t,
+		v,
+		"0000003e00000111000000000000000d616263001c1d416c69636572000001000000080006060006006e6f7274680606000600736f757468060400020003",
+		data.BROADCAST_SM,
+	)
+
+	t.Run("invalidArea", func(t *testing.T) {
+		_, err := Parse(NewBuffer(fromHex("0000002500000111000000000000000d000000000001000000000006060000060400020003")))
+		require.NotNil(t, err)
+	})
+}
+
+func TestBroadcastTLV(t *testing.T) {
+	require.Equal(t, Field{Tag: TagBroadcastContentType, Data: []byte{0x01, 0x01, 0x02}},
+		NewBroadcastContentType(data.BROADCAST_NETWORK_GSM, 0x0102))
+	require.Equal(t, Field{Tag: TagBroadcastFrequencyInterval, Data: []byte{0x09, 0x00, 0x0f}},
+		NewBroadcastFrequencyInterval(data.BROADCAST_FREQ_MINUTES, 15))
+	require.Equal(t, Field{Tag: TagBroadcastAreaIdentifier, Data: []byte{0x01, 0xaa}},
+		BroadcastArea{Format: data.BROADCAST_AREA_FORMAT_ELLIPSOID_ARC, Details: []byte{0xaa}}.Field())
+}
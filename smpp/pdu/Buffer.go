@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"sync"
+
 	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
 )
 
 const (
@@ -26,6 +30,12 @@ var (
 	ErrBufferNotEnoughByteToRead = fmt.Errorf("Not enough byte to read from buffer")
 
 	endianese = binary.BigEndian
+
+	bufferPool = sync.Pool{
+		New: func() interface{} {
+			return &ByteBuffer{Buffer: new(bytes.Buffer)}
+		},
+	}
 )
 
 // ByteBuffer wraps over bytes.Buffer with additional features.
@@ -41,6 +51,23 @@ func NewBuffer(inp []byte) *ByteBuffer {
 	return &ByteBuffer{Buffer: bytes.NewBuffer(inp)}
 }
 
+// AcquireBuffer returns empty buffer from pool.
+// Once its bytes are no longer referenced, the buffer should be returned by ReleaseBuffer.
+func AcquireBuffer() *ByteBuffer {
+	b := bufferPool.Get().(*ByteBuffer)
+	b.Reset()
+	return b
+}
+
+// ReleaseBuffer returns buffer to pool. Buffers grown beyond maximum PDU length are dropped.
+func ReleaseBuffer(b *ByteBuffer) {
+	if b == nil || b.Buffer == nil || b.Cap() > data.MAX_PDU_LEN {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
 // ReadN read n-bytes from buffer.
 func (c *ByteBuffer) ReadN(n int) (r []byte, err error) {
 	if n > 0 {
@@ -54,9 +81,17 @@ func (c *ByteBuffer) ReadN(n int) (r []byte, err error) {
 	return
 }
 
+// next returns next n-bytes without copying, valid until the buffer is modified.
+func (c *ByteBuffer) next(n int) (r []byte, err error) {
+	if c.Len() < n {
+		return nil, ErrBufferNotEnoughByteToRead
+	}
+	return c.Next(n), nil
+}
+
 // ReadShort reads short from buffer.
 func (c *ByteBuffer) ReadShort() (r int16, err error) {
-	v, err := c.ReadN(SizeShort)
+	v, err := c.next(SizeShort)
 	if err == nil {
 		r = int16(endianese.Uint16(v))
 	}
@@ -72,7 +107,7 @@ func (c *ByteBuffer) WriteShort(v int16) {
 
 // ReadInt reads int from buffer.
 func (c *ByteBuffer) ReadInt() (r int32, err error) {
-	v, err := c.ReadN(SizeInt)
+	v, err := c.next(SizeInt)
 	if err == nil {
 		r = int32(endianese.Uint32(v))
 	}
@@ -110,7 +145,9 @@ func (c *ByteBuffer) writeString(st string, isCString bool, enc coding.Encoding)
 
 // WriteCString writes c-string.
 func (c *ByteBuffer) WriteCString(s string) error {
-	return c.writeString(s, true, coding.ASCII)
+	// ascii encoding is identity, so skip copying string into encoded slice
+	_, _ = c.WriteString(s)
+	return c.WriteByte(0)
 }
 
 // WriteCStringWithEnc write c-string with encoding.
@@ -120,10 +157,14 @@ func (c *ByteBuffer) WriteCStringWithEnc(s string, enc coding.Encoding) error {
 
 // ReadCString read c-string.
 func (c *ByteBuffer) ReadCString() (st string, err error) {
-	buf, err := c.ReadBytes(0)
-	if err == nil && len(buf) > 0 { // optimistic branching
-		st = string(buf[:len(buf)-1])
+	i := bytes.IndexByte(c.Bytes(), 0)
+	if i < 0 {
+		// consume the rest, as reading until missing delimiter does
+		c.Next(c.Len())
+		return "", io.EOF
 	}
+
+	st = string(c.Next(i + 1)[:i])
 	return
 }
 
@@ -2,6 +2,8 @@ package pdu
 
 import (
 	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"io"
 	"strings"
 	"testing"
 
@@ -13,3 +15,37 @@ func TestBuffer(t *testing.T) {
 	require.Nil(t, b.WriteCStringWithEnc("agjwklgjkwPץ", coding.HEBREW))
 	require.Equal(t, "61676A776B6C676A6B7750F500", strings.ToUpper(b.HexDump()))
 }
+
+func TestBufferPool(t *testing.T) {
+	b := AcquireBuffer()
+	require.Nil(t, b.WriteCString("abc"))
+	ReleaseBuffer(b)
+
+	b = AcquireBuffer()
+	require.Zero(t, b.Len())
+	ReleaseBuffer(b)
+
+	// oversized buffer is not pooled, nil is ignored
+	ReleaseBuffer(NewBuffer(make([]byte, 0, 2*data.MAX_PDU_LEN)))
+	ReleaseBuffer(nil)
+}
+
+func TestBufferReadCString(t *testing.T) {
+	b := NewBuffer([]byte("abc\x00\x00de"))
+
+	s, err := b.ReadCString()
+	require.Nil(t, err)
+	require.Equal(t, "abc", s)
+
+	s, err = b.ReadCString()
+	require.Nil(t, err)
+	require.Equal(t, "", s)
+
+	_, err = b.ReadCString()
+	require.Equal(t, io.EOF, err)
+	require.Zero(t, b.Len())
+
+	v, err := NewBuffer([]byte{1, 2, 3}).ReadInt()
+	require.Equal(t, ErrBufferNotEnoughByteToRead, err)
+	require.Zero(t, v)
+}
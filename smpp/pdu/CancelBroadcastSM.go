@@ -0,0 +1,61 @@
+package pdu
+
+import (
+	"github.com/sujit-baniya/protocol/smpp/data"
+)
+
+// CancelBroadcastSM PDU (SMPP v5.0) is issued by the ESME to cancel a broadcast message which
+// has been previously submitted to the SMSC for broadcast. The command may specify a particular
+// message by message_id, or all messages matching source address and broadcast_content_type
+// (or user_message_reference) TLV.
+type CancelBroadcastSM struct {
+	base
+	ServiceType string
+	MessageID   string
+	SourceAddr  Address
+}
+
+// NewCancelBroadcastSM returns CancelBroadcastSM PDU.
+func NewCancelBroadcastSM() PDU {
+	c := &CancelBroadcastSM{
+		base:        newBase(),
+		ServiceType: data.DFLT_SRVTYPE,
+		MessageID:   data.DFLT_MSGID,
+		SourceAddr:  NewAddress(),
+	}
+	c.CommandID = data.CANCEL_BROADCAST_SM
+	return c
+}
+
+// CanResponse implements PDU interface.
+func (c *CancelBroadcastSM) CanResponse() bool {
+	return true
+}
+
+// GetResponse implements PDU interface.
+func (c *CancelBroadcastSM) GetResponse() PDU {
+	return NewCancelBroadcastSMRespFromReq(c)
+}
+
+// Marshal implements PDU interface.
+func (c *CancelBroadcastSM) Marshal(b *ByteBuffer) {
+	c.base.marshal(b, func(b *ByteBuffer) {
+		b.Grow(len(c.ServiceType) + len(c.MessageID) + 2)
+
+		_ = b.WriteCString(c.ServiceType)
+		_ = b.WriteCString(c.MessageID)
+		c.SourceAddr.Marshal(b)
+	})
+}
+
+// Unmarshal implements PDU interface.
+func (c *CancelBroadcastSM) Unmarshal(b *ByteBuffer) error {
+	return c.base.unmarshal(b, func(b *ByteBuffer) (err error) {
+		if c.ServiceType, err = b.ReadCString(); err == nil {
+			if c.MessageID, err = b.ReadCString(); err == nil {
+				err = c.SourceAddr.Unmarshal(b)
+			}
+		}
+		return
+	})
+}
@@ -0,0 +1,33 @@
+package pdu
+
+import (
+	"testing"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelBroadcastSM(t *testing.T) {
+	v := NewCancelBroadcastSM().(*CancelBroadcastSM)
+	require.True(t, v.CanResponse())
+	v.SequenceNumber = 13
+
+	validate(t,
+		v.GetResponse(),
+		"0000001080000113000000000000000d",
+		data.CANCEL_BROADCAST_SM_RESP,
+	)
+
+	v.ServiceType = "abc"
+	v.MessageID = "foo"
+	_ = v.SourceAddr.SetAddress("Alicer")
+	v.SourceAddr.SetTon(28)
+	v.SourceAddr.SetNpi(29)
+
+	validate(t,
+		v,
+		"0000002100000113000000000000000d61626300666f6f001c1d416c6963657200",
+		data.CANCEL_BROADCAST_SM,
+	)
+}
@@ -27,7 +27,7 @@ func TestDeliverSM(t *testing.T) {
 	_ = v.DestAddr.SetAddress("Bobo")
 	v.DestAddr.SetTon(30)
 	v.DestAddr.SetNpi(31)
-	v.EsmClass = 77
+	v.EsmClass = 77 ^ data.SM_UDH_GSM
 	v.ProtocolID = 99
 	v.PriorityFlag = 61
 	v.RegisteredDelivery = 83
@@ -36,7 +36,7 @@ func TestDeliverSM(t *testing.T) {
 
 	validate(t,
 		v,
-		"0000005e00000005000000000000000d616263001c1d416c69636572001e1f426f626f004d633d00005300080030006e006700681eaf0020006e00670068006900ea006e00670020006e0067006800691ec5006e00670020006e00671ea3",
+		"0000005e00000005000000000000000d616263001c1d416c69636572001e1f426f626f000d633d00005300080030006e006700681eaf0020006e00670068006900ea006e00670020006e0067006800691ec5006e00670020006e00671ea3",
 		data.DELIVER_SM,
 	)
 }
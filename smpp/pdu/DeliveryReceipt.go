@@ -0,0 +1,293 @@
+package pdu
+
+import (
+	"encoding/binary"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/errors"
+)
+
+// MessageState represents state of short message at SMSC.
+type MessageState byte
+
+// Message states, as defined in SMPP v3.4 section 5.2.28 and SMPP v5.0 section 4.7.15.
+const (
+	MessageStateScheduled     MessageState = 0
+	MessageStateEnroute       MessageState = data.SM_STATE_EN_ROUTE
+	MessageStateDelivered     MessageState = data.SM_STATE_DELIVERED
+	MessageStateExpired       MessageState = data.SM_STATE_EXPIRED
+	MessageStateDeleted       MessageState = data.SM_STATE_DELETED
+	MessageStateUndeliverable MessageState = data.SM_STATE_UNDELIVERABLE
+	MessageStateAccepted      MessageState = data.SM_STATE_ACCEPTED
+	MessageStateUnknown       MessageState = data.SM_STATE_INVALID
+	MessageStateRejected      MessageState = data.SM_STATE_REJECTED
+	MessageStateSkipped       MessageState = 9
+)
+
+// String returns stat value used within delivery receipt text.
+func (s MessageState) String() string {
+	switch s {
+	case MessageStateScheduled:
+		return "SCHEDULED"
+	case MessageStateEnroute:
+		return "ENROUTE"
+	case MessageStateDelivered:
+		return "DELIVRD"
+	case MessageStateExpired:
+		return "EXPIRED"
+	case MessageStateDeleted:
+		return "DELETED"
+	case MessageStateUndeliverable:
+		return "UNDELIV"
+	case MessageStateAccepted:
+		return "ACCEPTD"
+	case MessageStateRejected:
+		return "REJECTD"
+	case MessageStateSkipped:
+		return "SKIPPED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// IsFinal returns true if message will not change its state anymore.
+// ACCEPTD and UNKNOWN are final states of SMPP v3.4 (5.2.28).
+func (s MessageState) IsFinal() bool {
+	switch s {
+	case MessageStateScheduled, MessageStateEnroute:
+		return false
+	default:
+		return true
+	}
+}
+
+// ParseMessageState parses stat value of delivery receipt text.
+// Both abbreviated (DELIVRD) and full (DELIVERED) forms are accepted.
+func ParseMessageState(stat string) MessageState {
+	switch strings.ToUpper(strings.TrimSpace(stat)) {
+	case "SCHEDULED":
+		return MessageStateScheduled
+	case "ENROUTE", "EN_ROUTE":
+		return MessageStateEnroute
+	case "DELIVRD", "DELIVERED", "DELIVER":
+		return MessageStateDelivered
+	case "EXPIRED":
+		return MessageStateExpired
+	case "DELETED":
+		return MessageStateDeleted
+	case "UNDELIV", "UNDELIVERABLE", "UNDELIVERED":
+		return MessageStateUndeliverable
+	case "ACCEPTD", "ACCEPTED":
+		return MessageStateAccepted
+	case "REJECTD", "REJECTED":
+		return MessageStateRejected
+	case "SKIPPED":
+		return MessageStateSkipped
+	default:
+		return MessageStateUnknown
+	}
+}
+
+// NetworkErrorCode is value of network_error_code TLV.
+type NetworkErrorCode struct {
+	// NetworkType indicates network type: 1 = ANSI-136, 2 = IS-95, 3 = GSM, ...
+	NetworkType byte
+	ErrorCode   uint16
+}
+
+// DeliveryReceipt represents SMSC delivery receipt carried by deliver_sm or data_sm.
+type DeliveryReceipt struct {
+	// MessageID is id of the original message, as returned in submit_sm_resp.
+	MessageID string
+
+	// Submitted is number of short messages originally submitted.
+	Submitted int
+
+	// Delivered is number of short messages delivered.
+	Delivered int
+
+	// SubmitDate is time when the original message was submitted.
+	SubmitDate time.Time
+
+	// DoneDate is time when the original message reached its final state.
+	DoneDate time.Time
+
+	// State is final state of the original message.
+	State MessageState
+
+	// Stat is raw stat value, as written in receipt text.
+	Stat string
+
+	// Err is network or SMSC specific error code, as written in receipt text.
+	Err string
+
+	// Text is the first characters of the original message.
+	Text string
+
+	// NetworkError is value of network_error_code TLV, nil if absent.
+	NetworkError *NetworkErrorCode
+}
+
+// esmClassMessageTypeMask selects message type bits (2-5) of esm_class.
+const esmClassMessageTypeMask = 0x3C
+
+var (
+	receiptFieldRegex = regexp.MustCompile(`(?i)\b(id|sub|dlvrd|submit[ _]?date|done[ _]?date|stat|err|text)\s*:`)
+
+	receiptDateLayouts = map[int]string{
+		10: "0601021504",
+		12: "060102150405",
+		14: "20060102150405",
+	}
+)
+
+// IsDeliveryReceipt returns true if esm_class indicates SMSC delivery receipt or intermediate notification.
+func IsDeliveryReceipt(esmClass byte) bool {
+	switch esmClass & esmClassMessageTypeMask {
+	case data.SM_SMSC_DLV_RCPT_TYPE, data.SM_INTMD_DLV_NOTIFY_TYPE:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsDeliveryReceipt returns true if DeliverSM is SMSC delivery receipt.
+func (c *DeliverSM) IsDeliveryReceipt() bool {
+	return IsDeliveryReceipt(c.EsmClass)
+}
+
+// IsDeliveryReceipt returns true if DataSM is SMSC delivery receipt.
+func (c *DataSM) IsDeliveryReceipt() bool {
+	return IsDeliveryReceipt(c.EsmClass)
+}
+
+// ParseDeliveryReceipt parses delivery receipt from DeliverSM.
+//
+// Receipted_message_id, message_state and network_error_code TLVs take
+// precedence over the values written in receipt text.
+// Dates are interpreted as UTC since receipt text carries no time zone.
+func ParseDeliveryReceipt(p *DeliverSM) (r *DeliveryReceipt, err error) {
+	if !p.IsDeliveryReceipt() {
+		return nil, errors.ErrNotDeliveryReceipt
+	}
+
+	text, err := p.Message.GetMessage()
+	if err != nil {
+		return
+	}
+
+	return parseDeliveryReceipt(text, p.OptionalParameters)
+}
+
+// ParseDataSMDeliveryReceipt parses delivery receipt from DataSM.
+//
+// Receipt text is read from message_payload TLV if present.
+func ParseDataSMDeliveryReceipt(p *DataSM) (r *DeliveryReceipt, err error) {
+	if !p.IsDeliveryReceipt() {
+		return nil, errors.ErrNotDeliveryReceipt
+	}
+
+	var text string
+	if payload, ok := p.OptionalParameters[TagMessagePayload]; ok && len(payload.Data) > 0 {
+		enc := coding.FromDataCoding(p.DataCoding)
+		if enc == nil {
+			enc = coding.GSM7BIT
+		}
+		if text, err = enc.Decode(payload.Data); err != nil {
+			return
+		}
+	}
+
+	return parseDeliveryReceipt(text, p.OptionalParameters)
+}
+
+func parseDeliveryReceipt(text string, tlv map[Tag]Field) (r *DeliveryReceipt, err error) {
+	r = &DeliveryReceipt{State: MessageStateUnknown}
+
+	fields := parseReceiptText(text)
+	for key, value := range fields {
+		switch key {
+		case "id":
+			r.MessageID = value
+		case "sub":
+			r.Submitted, _ = strconv.Atoi(value)
+		case "dlvrd":
+			r.Delivered, _ = strconv.Atoi(value)
+		case "submitdate":
+			r.SubmitDate = parseReceiptDate(value)
+		case "donedate":
+			r.DoneDate = parseReceiptDate(value)
+		case "stat":
+			r.Stat = value
+			r.State = ParseMessageState(value)
+		case "err":
+			r.Err = value
+		case "text":
+			r.Text = value
+		}
+	}
+
+	if f, ok := tlv[TagReceiptedMessageID]; ok {
+		if id := f.String(); id != "" {
+			r.MessageID = id
+		}
+	}
+
+	if f, ok := tlv[TagMessageStateOption]; ok && len(f.Data) == 1 {
+		r.State = MessageState(f.Data[0])
+		if r.Stat == "" {
+			r.Stat = r.State.String()
+		}
+	}
+
+	if f, ok := tlv[TagNetworkErrorCode]; ok && len(f.Data) == 3 {
+		r.NetworkError = &NetworkErrorCode{
+			NetworkType: f.Data[0],
+			ErrorCode:   binary.BigEndian.Uint16(f.Data[1:]),
+		}
+	}
+
+	if r.MessageID == "" {
+		r, err = nil, errors.ErrInvalidDeliveryReceipt
+	}
+	return
+}
+
+// parseReceiptText splits receipt text into lower-cased keys and their values.
+// Everything after "text:" belongs to text field.
+func parseReceiptText(text string) map[string]string {
+	fields := make(map[string]string)
+
+	matches := receiptFieldRegex.FindAllStringSubmatchIndex(text, -1)
+	for i, m := range matches {
+		key := strings.ToLower(text[m[2]:m[3]])
+		key = strings.NewReplacer(" ", "", "_", "").Replace(key)
+
+		end := len(text)
+		if key != "text" && i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+
+		if _, ok := fields[key]; !ok {
+			fields[key] = strings.TrimSpace(text[m[1]:end])
+		}
+
+		if key == "text" {
+			break
+		}
+	}
+
+	return fields
+}
+
+func parseReceiptDate(value string) (t time.Time) {
+	if layout, ok := receiptDateLayouts[len(value)]; ok {
+		t, _ = time.ParseInLocation(layout, value, time.UTC)
+	}
+	return
+}
@@ -0,0 +1,165 @@
+package pdu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newReceipt(t *testing.T, text string) *DeliverSM {
+	p := NewDeliverSM().(*DeliverSM)
+	p.EsmClass = data.SM_SMSC_DLV_RCPT_TYPE
+	require.NoError(t, p.Message.SetMessageWithEncoding(text, coding.ASCII))
+	return p
+}
+
+func TestParseDeliveryReceipt(t *testing.T) {
+	t.Run("standard", func(t *testing.T) {
+		p := newReceipt(t, "id:0123456789 sub:001 dlvrd:001 submit date:2106241530 done date:2106241531 stat:DELIVRD err:000 text:Hello world")
+
+		r, err := ParseDeliveryReceipt(p)
+		require.NoError(t, err)
+		require.Equal(t, &DeliveryReceipt{
+			MessageID:  "0123456789",
+			Submitted:  1,
+			Delivered:  1,
+			SubmitDate: time.Date(2021, 6, 24, 15, 30, 0, 0, time.UTC),
+			DoneDate:   time.Date(2021, 6, 24, 15, 31, 0, 0, time.UTC),
+			State:      MessageStateDelivered,
+			Stat:       "DELIVRD",
+			Err:        "000",
+			Text:       "Hello world",
+		}, r)
+		require.True(t, r.State.IsFinal())
+	})
+
+	t.Run("vendor variations", func(t *testing.T) {
+		p := newReceipt(t, "ID:abc-def Sub:1 Dlvrd:0 Submit_Date:210624153045 Done_Date:20210624153110 Stat:UNDELIVERABLE Err:34 Text:stat:fake")
+
+		r, err := ParseDeliveryReceipt(p)
+		require.NoError(t, err)
+		require.Equal(t, "abc-def", r.MessageID)
+		require.Equal(t, 1, r.Submitted)
+		require.Equal(t, 0, r.Delivered)
+		require.Equal(t, time.Date(2021, 6, 24, 15, 30, 45, 0, time.UTC), r.SubmitDate)
+		require.Equal(t, time.Date(2021, 6, 24, 15, 31, 10, 0, time.UTC), r.DoneDate)
+		require.Equal(t, MessageStateUndeliverable, r.State)
+		require.Equal(t, "34", r.Err)
+		require.Equal(t, "stat:fake", r.Text)
+	})
+
+	t.Run("missing fields", func(t *testing.T) {
+		r, err := ParseDeliveryReceipt(newReceipt(t, "id:42 stat:EXPIRED"))
+		require.NoError(t, err)
+		require.Equal(t, "42", r.MessageID)
+		require.Equal(t, MessageStateExpired, r.State)
+		require.True(t, r.SubmitDate.IsZero())
+		require.Empty(t, r.Text)
+	})
+
+	t.Run("TLVs take precedence", func(t *testing.T) {
+		p := newReceipt(t, "id:1f sub:001 dlvrd:000 submit date:2106241530 done date:2106241531 stat:ENROUTE err:000 text:")
+		p.RegisterOptionalParam(Field{Tag: TagReceiptedMessageID, Data: []byte("31\x00")})
+		p.RegisterOptionalParam(Field{Tag: TagMessageStateOption, Data: []byte{data.SM_STATE_REJECTED}})
+		p.RegisterOptionalParam(Field{Tag: TagNetworkErrorCode, Data: []byte{0x03, 0x00, 0x22}})
+
+		r, err := ParseDeliveryReceipt(p)
+		require.NoError(t, err)
+		require.Equal(t, "31", r.MessageID)
+		require.Equal(t, MessageStateRejected, r.State)
+		require.Equal(t, "ENROUTE", r.Stat)
+		require.Equal(t, &NetworkErrorCode{NetworkType: 3, ErrorCode: 0x22}, r.NetworkError)
+	})
+
+	t.Run("TLVs only", func(t *testing.T) {
+		p := newReceipt(t, "")
+		p.RegisterOptionalParam(Field{Tag: TagReceiptedMessageID, Data: []byte("31\x00")})
+		p.RegisterOptionalParam(Field{Tag: TagMessageStateOption, Data: []byte{data.SM_STATE_DELIVERED}})
+
+		r, err := ParseDeliveryReceipt(p)
+		require.NoError(t, err)
+		require.Equal(t, "31", r.MessageID)
+		require.Equal(t, MessageStateDelivered, r.State)
+		require.Equal(t, "DELIVRD", r.Stat)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		p := newReceipt(t, "id:1 stat:DELIVRD")
+		p.EsmClass = data.SM_UDH_GSM
+
+		_, err := ParseDeliveryReceipt(p)
+		require.Equal(t, errors.ErrNotDeliveryReceipt, err)
+
+		_, err = ParseDeliveryReceipt(newReceipt(t, "hello world"))
+		require.Equal(t, errors.ErrInvalidDeliveryReceipt, err)
+	})
+
+	t.Run("after parsing from wire", func(t *testing.T) {
+		p := newReceipt(t, "id:7 sub:001 dlvrd:001 submit date:2106241530 done date:2106241531 stat:DELIVRD err:000 text:")
+		buf := NewBuffer(nil)
+		p.Marshal(buf)
+
+		parsed, err := Parse(buf)
+		require.NoError(t, err)
+
+		r, err := ParseDeliveryReceipt(parsed.(*DeliverSM))
+		require.NoError(t, err)
+		require.Equal(t, "7", r.MessageID)
+		require.Equal(t, MessageStateDelivered, r.State)
+	})
+}
+
+func TestParseDataSMDeliveryReceipt(t *testing.T) {
+	p := NewDataSM().(*DataSM)
+	p.EsmClass = data.SM_SMSC_DLV_RCPT_TYPE
+	p.RegisterOptionalParam(Field{Tag: TagMessagePayload, Data: []byte("id:99 sub:001 dlvrd:000 submit date:2106241530 done date:2106241531 stat:REJECTD err:011 text:x")})
+	p.RegisterOptionalParam(Field{Tag: TagNetworkErrorCode, Data: []byte{0x03, 0x01, 0x00}})
+
+	r, err := ParseDataSMDeliveryReceipt(p)
+	require.NoError(t, err)
+	require.Equal(t, "99", r.MessageID)
+	require.Equal(t, MessageStateRejected, r.State)
+	require.Equal(t, "011", r.Err)
+	require.Equal(t, uint16(0x100), r.NetworkError.ErrorCode)
+
+	p.EsmClass = 0
+	_, err = ParseDataSMDeliveryReceipt(p)
+	require.Equal(t, errors.ErrNotDeliveryReceipt, err)
+}
+
+func TestMessageState(t *testing.T) {
+	for _, s := range []MessageState{
+		MessageStateScheduled, MessageStateEnroute, MessageStateDelivered, MessageStateExpired,
+		MessageStateDeleted, MessageStateUndeliverable, MessageStateAccepted, MessageStateUnknown,
+		MessageStateRejected, MessageStateSkipped,
+	} {
+		require.Equal(t, s, ParseMessageState(s.String()))
+	}
+	require.Equal(t, MessageStateDelivered, ParseMessageState("delivered"))
+	require.Equal(t, MessageStateUnknown, ParseMessageState("whatever"))
+}
+
+func TestMessageStateIsFinal(t *testing.T) {
+	for _, tc := range []struct {
+		state MessageState
+		final bool
+	}{
+		{MessageStateScheduled, false},
+		{MessageStateEnroute, false},
+		{MessageStateDelivered, true},
+		{MessageStateExpired, true},
+		{MessageStateDeleted, true},
+		{MessageStateUndeliverable, true},
+		{MessageStateAccepted, true},
+		{MessageStateUnknown, true},
+		{MessageStateRejected, true},
+		{MessageStateSkipped, true},
+	} {
+		require.Equal(t, tc.final, tc.state.IsFinal(), tc.state.String())
+	}
+}
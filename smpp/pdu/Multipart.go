@@ -0,0 +1,165 @@
+package pdu
+
+import (
+	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/errors"
+)
+
+// Segmentation is the method used to carry message longer than a single short message.
+type Segmentation byte
+
+const (
+	// SegmentationUDH8 splits message into short messages with 8-bit reference concatenation UDH.
+	SegmentationUDH8 Segmentation = iota
+
+	// SegmentationUDH16 splits message into short messages with 16-bit reference concatenation UDH.
+	SegmentationUDH16
+
+	// SegmentationSAR splits message into short messages with sar_msg_ref_num,
+	// sar_total_segments and sar_segment_seqnum TLVs.
+	SegmentationSAR
+
+	// SegmentationPayload carries whole message within message_payload TLV of a single PDU.
+	SegmentationPayload
+)
+
+// udhLen returns octets reserved for concatenation UDH.
+func (s Segmentation) udhLen() uint {
+	switch s {
+	case SegmentationUDH8:
+		return 6
+	case SegmentationUDH16:
+		return 7
+	default:
+		return 0
+	}
+}
+
+// MultipartOptions for ComposeMultipart.
+type MultipartOptions struct {
+	// Encoding of message. Nil uses coding.BestSafeCoding.
+	Encoding coding.Encoding
+
+	// Segmentation method.
+	Segmentation Segmentation
+
+	// Reference is concatenated message reference number shared by all segments.
+	// SegmentationUDH8 uses its low byte only.
+	Reference uint16
+}
+
+// ComposeMultipart composes ready to submit SubmitSM PDUs carrying message.
+//
+// Addresses, esm_class, registered_delivery and other fields, as well as optional parameters,
+// are copied from template. Nil template uses NewSubmitSM defaults.
+// Each PDU has its own sequence number.
+//
+// Message which fits into a single short message is returned as single PDU without concatenation info.
+func ComposeMultipart(template *SubmitSM, message string, opts MultipartOptions) (multiSubSM []*SubmitSM, err error) {
+	if template == nil {
+		template = NewSubmitSM().(*SubmitSM)
+	}
+
+	enc := opts.Encoding
+	if enc == nil {
+		enc = coding.BestSafeCoding(message)
+	}
+
+	if opts.Segmentation == SegmentationPayload {
+		var ud []byte
+		if ud, err = enc.Encode(message); err != nil {
+			return
+		}
+		if len(ud) > 0xFFFF {
+			return nil, errors.ErrMessagePayloadTooLarge
+		}
+
+		c := template.clone()
+		c.Message = ShortMessage{enc: enc, withoutDataCoding: template.Message.withoutDataCoding}
+		c.RegisterOptionalParam(Field{Tag: TagMessagePayload, Data: ud})
+		return []*SubmitSM{c}, nil
+	}
+
+	if opts.Segmentation > SegmentationPayload {
+		return nil, errors.ErrUnknownSegmentation
+	}
+
+	segments, err := encodeSplit(message, enc, data.SM_GSM_MSG_LEN-opts.Segmentation.udhLen())
+	if err != nil {
+		return
+	}
+	if len(segments) > 255 {
+		return nil, errors.ErrTooManySegments
+	}
+
+	total := byte(len(segments))
+	multiSubSM = make([]*SubmitSM, 0, len(segments))
+
+	for i, seg := range segments {
+		c := template.clone()
+		c.Message = ShortMessage{enc: enc, messageData: seg, withoutDataCoding: template.Message.withoutDataCoding}
+
+		if total > 1 {
+			seq := byte(i + 1)
+
+			switch opts.Segmentation {
+			case SegmentationUDH8:
+				c.Message.udHeader = UDH{NewIEConcatMessage(total, seq, byte(opts.Reference))}
+				c.EsmClass |= data.SM_UDH_GSM // must set to indicate UDH
+
+			case SegmentationUDH16:
+				c.Message.udHeader = UDH{NewIEConcatMessage16(total, seq, opts.Reference)}
+				c.EsmClass |= data.SM_UDH_GSM // must set to indicate UDH
+
+			case SegmentationSAR:
+				c.RegisterOptionalParam(Field{Tag: TagSarMsgRefNum, Data: []byte{byte(opts.Reference >> 8), byte(opts.Reference)}})
+				c.RegisterOptionalParam(Field{Tag: TagSarTotalSegments, Data: []byte{total}})
+				c.RegisterOptionalParam(Field{Tag: TagSarSegmentSeqnum, Data: []byte{seq}})
+			}
+		}
+
+		multiSubSM = append(multiSubSM, c)
+	}
+
+	return
+}
+
+// encodeSplit encodes message into segments of at most octetLimit octets.
+// Single segment is returned if encoded message fits into a single short message.
+func encodeSplit(message string, enc coding.Encoding, octetLimit uint) (segments [][]byte, err error) {
+	if splitter, ok := enc.(coding.Splitter); ok {
+		if splitter.ShouldSplit(message, data.SM_GSM_MSG_LEN) {
+			return splitter.EncodeSplit(message, octetLimit)
+		}
+	}
+
+	ud, err := enc.Encode(message)
+	if err != nil {
+		return
+	}
+
+	if len(ud) <= data.SM_GSM_MSG_LEN {
+		return [][]byte{ud}, nil
+	}
+
+	// encoding without splitter is single octet per character
+	for len(ud) > int(octetLimit) {
+		segments = append(segments, ud[:octetLimit])
+		ud = ud[octetLimit:]
+	}
+	segments = append(segments, ud)
+
+	return
+}
+
+// clone returns copy of SubmitSM with new sequence number.
+func (c *SubmitSM) clone() *SubmitSM {
+	v := *c
+	v.base = newBase()
+	v.CommandID = data.SUBMIT_SM
+	for tag, field := range c.OptionalParameters {
+		v.OptionalParameters[tag] = field
+	}
+	return &v
+}
@@ -0,0 +1,145 @@
+package pdu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+// reparse marshals and parses SubmitSM back.
+func reparse(t *testing.T, c *SubmitSM) *SubmitSM {
+	buf := NewBuffer(nil)
+	c.Marshal(buf)
+
+	p, err := Parse(buf)
+	require.NoError(t, err)
+	return p.(*SubmitSM)
+}
+
+func TestComposeMultipart(t *testing.T) {
+	long := strings.Repeat("abcdefghij", 30)
+
+	template := NewSubmitSM().(*SubmitSM)
+	_ = template.DestAddr.SetAddress("1234")
+	template.RegisteredDelivery = data.SM_SMSC_RECEIPT_REQUESTED
+	template.RegisterOptionalParam(Field{Tag: TagUserMessageReference, Data: []byte{0x00, 0x01}})
+
+	t.Run("single", func(t *testing.T) {
+		multi, err := ComposeMultipart(template, "hello", MultipartOptions{Reference: 7})
+		require.NoError(t, err)
+		require.Len(t, multi, 1)
+
+		c := reparse(t, multi[0])
+		require.Equal(t, data.DFLT_ESM_CLASS, c.EsmClass)
+		require.Nil(t, c.Message.UDH())
+		require.NotContains(t, c.OptionalParameters, TagSarMsgRefNum)
+
+		message, err := c.Message.GetMessage()
+		require.NoError(t, err)
+		require.Equal(t, "hello", message)
+	})
+
+	t.Run("UDH 8-bit", func(t *testing.T) {
+		multi, err := ComposeMultipart(template, long, MultipartOptions{Reference: 0x0107})
+		require.NoError(t, err)
+		require.Len(t, multi, 3)
+
+		var text string
+		for i, m := range multi {
+			c := reparse(t, m)
+			require.Equal(t, "1234", c.DestAddr.Address())
+			require.Equal(t, data.SM_SMSC_RECEIPT_REQUESTED, c.RegisteredDelivery)
+			require.Contains(t, c.OptionalParameters, TagUserMessageReference)
+			require.NotZero(t, c.EsmClass&data.SM_UDH_GSM)
+
+			total, seq, ref, found := c.Message.UDH().GetConcatInfo()
+			require.True(t, found)
+			require.Equal(t, byte(3), total)
+			require.Equal(t, byte(i+1), seq)
+			require.Equal(t, byte(0x07), ref)
+
+			part, err := c.Message.GetMessage()
+			require.NoError(t, err)
+			text += part
+		}
+		require.Equal(t, long, text)
+		require.NotEqual(t, multi[0].GetSequenceNumber(), multi[1].GetSequenceNumber())
+	})
+
+	t.Run("UDH 16-bit", func(t *testing.T) {
+		multi, err := ComposeMultipart(template, "Đừng buồn thế dù ngoài kia vẫn mưa nghiễng rợi tý tỵ, Đừng buồn thế dù ngoài kia vẫn mưa", MultipartOptions{
+			Segmentation: SegmentationUDH16,
+			Reference:    0x1234,
+		})
+		require.NoError(t, err)
+		require.Len(t, multi, 2)
+
+		for i, m := range multi {
+			c := reparse(t, m)
+			require.Equal(t, coding.UCS2, c.Message.Encoding())
+
+			total, seq, ref, found := c.Message.UDH().GetConcatInfo16()
+			require.True(t, found)
+			require.Equal(t, byte(2), total)
+			require.Equal(t, byte(i+1), seq)
+			require.Equal(t, uint16(0x1234), ref)
+
+			ud, _ := c.Message.GetMessageData()
+			require.LessOrEqual(t, len(ud)+7, data.SM_GSM_MSG_LEN)
+		}
+	})
+
+	t.Run("SAR", func(t *testing.T) {
+		multi, err := ComposeMultipart(nil, long, MultipartOptions{
+			Segmentation: SegmentationSAR,
+			Reference:    0x1234,
+		})
+		require.NoError(t, err)
+		require.Len(t, multi, 3)
+
+		for i, m := range multi {
+			c := reparse(t, m)
+			require.Zero(t, c.EsmClass&data.SM_UDH_GSM)
+			require.Equal(t, []byte{0x12, 0x34}, c.OptionalParameters[TagSarMsgRefNum].Data)
+			require.Equal(t, []byte{3}, c.OptionalParameters[TagSarTotalSegments].Data)
+			require.Equal(t, []byte{byte(i + 1)}, c.OptionalParameters[TagSarSegmentSeqnum].Data)
+		}
+	})
+
+	t.Run("message_payload", func(t *testing.T) {
+		multi, err := ComposeMultipart(template, long, MultipartOptions{
+			Encoding:     coding.ASCII,
+			Segmentation: SegmentationPayload,
+		})
+		require.NoError(t, err)
+		require.Len(t, multi, 1)
+
+		c := reparse(t, multi[0])
+		ud, _ := c.Message.GetMessageData()
+		require.Empty(t, ud)
+		require.Equal(t, coding.ASCII, c.Message.Encoding())
+		require.Equal(t, []byte(long), c.OptionalParameters[TagMessagePayload].Data)
+	})
+
+	t.Run("encoding without splitter", func(t *testing.T) {
+		multi, err := ComposeMultipart(template, long, MultipartOptions{Encoding: coding.LATIN1})
+		require.NoError(t, err)
+		require.Len(t, multi, 3)
+
+		ud, _ := multi[0].Message.GetMessageData()
+		require.Len(t, ud, data.SM_GSM_MSG_LEN-6)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := ComposeMultipart(template, long, MultipartOptions{Segmentation: SegmentationPayload + 1})
+		require.Equal(t, errors.ErrUnknownSegmentation, err)
+
+		_, err = ComposeMultipart(template, strings.Repeat("a", 256*134), MultipartOptions{})
+		require.Equal(t, errors.ErrTooManySegments, err)
+	})
+}
@@ -1,7 +1,11 @@
 package pdu
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
 	"io"
+	"sync"
 
 	"github.com/go-errors/errors"
 	"github.com/sujit-baniya/protocol/smpp/data"
@@ -87,7 +91,7 @@ func (c *base) unmarshal(b *ByteBuffer, bodyReader func(*ByteBuffer) error) (err
 			// body < command_length, still have optional parameters ?
 			if got < cmdLength {
 				var optParam []byte
-				if optParam, err = b.ReadN(cmdLength - got); err == nil {
+				if optParam, err = b.next(cmdLength - got); err == nil {
 					err = c.unmarshalOptionalParam(optParam)
 				}
 				if err != nil {
@@ -118,26 +122,58 @@ func (c *base) unmarshalOptionalParam(optParam []byte) (err error) {
 	return
 }
 
+// unmarshalRepeatedParams reads optional parameters following mandatory body of read byte(s).
+// Fields taken by repeated are not stored into OptionalParameters, since their tag might occur
+// multiple times within a PDU.
+func (c *base) unmarshalRepeatedParams(b *ByteBuffer, read int, repeated func(Field) (bool, error)) (err error) {
+	remain := int(c.CommandLength) - data.PDU_HEADER_SIZE - read
+	if remain < 0 {
+		return constErrors.ErrInvalidPDU
+	}
+
+	var optParam []byte
+	if optParam, err = b.next(remain); err != nil {
+		return
+	}
+
+	buf := NewBuffer(optParam)
+	for buf.Len() > 0 {
+		var field Field
+		if err = field.Unmarshal(buf); err != nil {
+			return
+		}
+
+		var taken bool
+		if taken, err = repeated(field); err != nil {
+			return
+		}
+
+		if !taken {
+			c.OptionalParameters[field.Tag] = field
+		}
+	}
+	return
+}
+
 // Marshal to buffer.
 func (c *base) marshal(b *ByteBuffer, bodyWriter func(*ByteBuffer)) {
-	bodyBuf := NewBuffer(nil)
+	start := b.Len()
+
+	// write header, command length is known once body is written
+	c.Header.Marshal(b)
 
 	// body
 	if bodyWriter != nil {
-		bodyWriter(bodyBuf)
+		bodyWriter(b)
 	}
 
 	// optional body
 	for _, v := range c.OptionalParameters {
-		v.Marshal(bodyBuf)
+		v.Marshal(b)
 	}
 
-	// write header
-	c.CommandLength = int32(data.PDU_HEADER_SIZE + bodyBuf.Len())
-	c.Header.Marshal(b)
-
-	// write body and its optional params
-	b.WriteBuffer(bodyBuf)
+	c.CommandLength = int32(b.Len() - start)
+	binary.BigEndian.PutUint32(b.Bytes()[start:], uint32(c.CommandLength))
 }
 
 // RegisterOptionalParam register optional param.
@@ -155,15 +191,64 @@ func (c *base) IsGNack() bool {
 	return c.CommandID == data.GENERIC_NACK
 }
 
+// UnknownCommandError indicates PDU with unknown command_id is read.
+// The whole PDU is consumed, so following PDU(s) can still be parsed.
+type UnknownCommandError struct {
+	Header Header
+}
+
+// Error interface.
+func (e *UnknownCommandError) Error() string {
+	return constErrors.ErrUnknownCommandID.Error()
+}
+
+// Is reports whether target is ErrUnknownCommandID.
+func (e *UnknownCommandError) Is(target error) bool {
+	return target == constErrors.ErrUnknownCommandID
+}
+
+// BufferedReader is reader which PDU can be parsed from without copying, e.g. bufio.Reader.
+type BufferedReader interface {
+	io.Reader
+	Peek(n int) ([]byte, error)
+	Discard(n int) (int, error)
+}
+
+// viewPool keeps buffers viewing raw bytes of PDU being unmarshalled, e.g. peeked from BufferedReader.
+var viewPool = sync.Pool{
+	New: func() interface{} {
+		return &ByteBuffer{Buffer: new(bytes.Buffer)}
+	},
+}
+
 // Parse PDU from reader.
+//
+// If r is BufferedReader, PDU is unmarshalled straight from peeked bytes, which are discarded
+// once unmarshalling is done. Otherwise, or if PDU does not fit within buffer of r, it is read into pooled buffer.
+// Parsed PDU never references bytes of either buffer.
+//
+// Unknown command_id is reported with *UnknownCommandError.
 func Parse(r io.Reader) (pdu PDU, err error) {
-	var headerBytes [16]byte
+	if br, ok := r.(BufferedReader); ok {
+		return parseBuffered(br)
+	}
+	return parse(r)
+}
 
-	if _, err = io.ReadFull(r, headerBytes[:]); err != nil {
+func parse(r io.Reader) (pdu PDU, err error) {
+	buf := AcquireBuffer()
+	defer ReleaseBuffer(buf)
+
+	buf.Grow(data.PDU_HEADER_SIZE)
+	raw := buf.Bytes()[:data.PDU_HEADER_SIZE]
+	if _, err = io.ReadFull(r, raw); err != nil {
 		err = errors.Wrap(err, 0)
 		return
 	}
 
+	var headerBytes [16]byte
+	copy(headerBytes[:], raw)
+
 	header := ParseHeader(headerBytes)
 	if header.CommandLength < 16 || header.CommandLength > data.MAX_PDU_LEN {
 		err = errors.Wrap(constErrors.ErrInvalidPDU, 0)
@@ -171,22 +256,74 @@ func Parse(r io.Reader) (pdu PDU, err error) {
 	}
 
 	// read pdu body
-	bodyBytes := make([]byte, header.CommandLength-16)
-	if len(bodyBytes) > 0 {
-		if _, err = io.ReadFull(r, bodyBytes); err != nil {
+	buf.Grow(int(header.CommandLength))
+	raw = buf.Bytes()[:header.CommandLength]
+	copy(raw, headerBytes[:])
+	if len(raw) > data.PDU_HEADER_SIZE {
+		if _, err = io.ReadFull(r, raw[data.PDU_HEADER_SIZE:]); err != nil {
 			err = errors.Wrap(err, 0)
 			return
 		}
 	}
 
-	// try to create pdu
+	return unmarshal(header, raw)
+}
+
+func parseBuffered(r BufferedReader) (pdu PDU, err error) {
+	raw, err := r.Peek(data.PDU_HEADER_SIZE)
+	if err != nil {
+		_, _ = r.Discard(len(raw))
+		err = errors.Wrap(fullReadError(err, len(raw)), 0)
+		return
+	}
+
+	var headerBytes [16]byte
+	copy(headerBytes[:], raw)
+
+	header := ParseHeader(headerBytes)
+	if header.CommandLength < 16 || header.CommandLength > data.MAX_PDU_LEN {
+		_, _ = r.Discard(data.PDU_HEADER_SIZE)
+		err = errors.Wrap(constErrors.ErrInvalidPDU, 0)
+		return
+	}
+
+	length := int(header.CommandLength)
+	if raw, err = r.Peek(length); err == bufio.ErrBufferFull {
+		// PDU does not fit within buffer of the reader
+		return parse(r)
+	}
+	if err != nil {
+		_, _ = r.Discard(len(raw))
+		err = errors.Wrap(fullReadError(err, len(raw)-data.PDU_HEADER_SIZE), 0)
+		return
+	}
+
+	// peeked bytes stay valid until discarded
+	pdu, err = unmarshal(header, raw)
+	_, _ = r.Discard(length)
+	return
+}
+
+// fullReadError converts error of reading n bytes as io.ReadFull does.
+func fullReadError(err error, n int) error {
+	if err == io.EOF && n > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// unmarshal PDU from its raw bytes. Raw bytes are not referenced by the PDU.
+func unmarshal(header Header, raw []byte) (pdu PDU, err error) {
 	if pdu, err = CreatePDUFromCmdID(header.CommandID); err == nil {
-		buf := NewBuffer(make([]byte, 0, header.CommandLength))
-		_, _ = buf.Write(headerBytes[:])
-		if len(bodyBytes) > 0 {
-			_, _ = buf.Write(bodyBytes)
-		}
+		buf := viewPool.Get().(*ByteBuffer)
+		*buf.Buffer = *bytes.NewBuffer(raw)
+
 		err = pdu.Unmarshal(buf)
+
+		*buf.Buffer = bytes.Buffer{}
+		viewPool.Put(buf)
+	} else if err == constErrors.ErrUnknownCommandID {
+		err = &UnknownCommandError{Header: header}
 	}
 	if err != nil {
 		err = errors.Wrap(err, 0)
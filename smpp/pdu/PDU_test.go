@@ -1,9 +1,14 @@
 package pdu
 
 import (
+	"bufio"
+	"bytes"
+	"io"
 	"testing"
 
 	libErrors "github.com/go-errors/errors"
+	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
 	"github.com/sujit-baniya/protocol/smpp/errors"
 	"github.com/stretchr/testify/require"
 )
@@ -31,6 +36,21 @@ func TestParsePDU(t *testing.T) {
 		require.True(t, libErrors.Is(errors.ErrInvalidPDU, err))
 	})
 
+	t.Run("unknownCommandID", func(t *testing.T) {
+		buf := NewBuffer(fromHex("0000001400000999000000000000000701020304" + "00000010800000060000000000000001"))
+		_, err := Parse(buf)
+		require.True(t, libErrors.Is(err, errors.ErrUnknownCommandID))
+
+		var unknown *UnknownCommandError
+		require.True(t, libErrors.As(err, &unknown))
+		require.EqualValues(t, 0x999, unknown.Header.CommandID)
+		require.EqualValues(t, 7, unknown.Header.SequenceNumber)
+
+		// following PDU is still parsable
+		_, err = Parse(buf)
+		require.Nil(t, err)
+	})
+
 	t.Run("invalidBody", func(t *testing.T) {
 		buf := NewBuffer(fromHex("0000001e00000003000000000000000161776179001c1d416c69636572"))
 		_, err := Parse(buf)
@@ -51,3 +71,134 @@ func TestParsePDU(t *testing.T) {
 		}))
 	})
 }
+
+func TestParseBuffered(t *testing.T) {
+	submitSM := NewSubmitSM().(*SubmitSM)
+	_ = submitSM.SourceAddr.SetAddress("sender")
+	_ = submitSM.DestAddr.SetAddress("receiver")
+	require.Nil(t, submitSM.Message.SetMessageWithEncoding("hello", coding.GSM7BIT))
+	submitSM.RegisterOptionalParam(Field{Tag: TagMessagePayload, Data: make([]byte, 200)})
+
+	stream := NewBuffer(nil)
+	submitSM.Marshal(stream)
+	stream.Write(fromHex("0000001400000999000000000000000701020304"))
+	stream.Write(fromHex("00000010800000060000000000000001"))
+	raw := append([]byte(nil), stream.Bytes()...)
+
+	// the smallest buffer makes submit_sm fall back to copying
+	for _, size := range []int{16, 4096} {
+		r := bufio.NewReaderSize(bytes.NewReader(raw), size)
+
+		p, err := Parse(r)
+		require.Nil(t, err)
+		parsed := p.(*SubmitSM)
+		require.Equal(t, "receiver", parsed.DestAddr.Address())
+		message, err := parsed.Message.GetMessage()
+		require.Nil(t, err)
+		require.Equal(t, "hello", message)
+		require.Equal(t, submitSM.OptionalParameters, parsed.OptionalParameters)
+
+		_, err = Parse(r)
+		require.True(t, libErrors.Is(err, errors.ErrUnknownCommandID))
+
+		p, err = Parse(r)
+		require.Nil(t, err)
+		require.Equal(t, data.UNBIND_RESP, p.GetHeader().CommandID)
+
+		_, err = Parse(r)
+		require.True(t, libErrors.Is(err, io.EOF))
+	}
+
+	r := bufio.NewReader(bytes.NewReader(fromHex("0000000f8000000600000000000000010000")))
+	_, err := Parse(r)
+	require.True(t, libErrors.Is(err, errors.ErrInvalidPDU))
+	require.Equal(t, 2, r.Buffered())
+
+	r = bufio.NewReader(bytes.NewReader(fromHex("0000001400000006000000000000000101")))
+	_, err = Parse(r)
+	require.True(t, libErrors.Is(err, io.ErrUnexpectedEOF))
+
+	r = bufio.NewReader(bytes.NewReader(fromHex("000000")))
+	_, err = Parse(r)
+	require.True(t, libErrors.Is(err, io.ErrUnexpectedEOF))
+
+	// the same PDU parsed from plain reader
+	_, err = Parse(bytes.NewReader(fromHex("0000001400000006000000000000000101")))
+	require.True(t, libErrors.Is(err, io.ErrUnexpectedEOF))
+}
+
+func benchmarkSubmitSM() []byte {
+	submitSM := NewSubmitSM().(*SubmitSM)
+	_ = submitSM.SourceAddr.SetAddress("sender")
+	_ = submitSM.DestAddr.SetAddress("receiver")
+	_ = submitSM.Message.SetMessageWithEncoding("hello world", coding.GSM7BIT)
+
+	buf := NewBuffer(nil)
+	submitSM.Marshal(buf)
+	return buf.Bytes()
+}
+
+func BenchmarkParse(b *testing.B) {
+	raw := benchmarkSubmitSM()
+
+	benchmark := func(b *testing.B, parse func(io.Reader) (PDU, error)) {
+		r := bytes.NewReader(raw)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r.Reset(raw)
+			if _, err := parse(r); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("baseline", func(b *testing.B) {
+		benchmark(b, parseUnpooled)
+	})
+	b.Run("pooled", func(b *testing.B) {
+		benchmark(b, Parse)
+	})
+	b.Run("buffered", func(b *testing.B) {
+		src := bytes.NewReader(raw)
+		r := bufio.NewReader(src)
+		benchmark(b, func(io.Reader) (PDU, error) {
+			src.Reset(raw)
+			r.Reset(src)
+			return Parse(r)
+		})
+	})
+}
+
+// parseUnpooled parses PDU into freshly allocated buffers, as Parse did before pooling them.
+func parseUnpooled(r io.Reader) (pdu PDU, err error) {
+	var headerBytes [16]byte
+	if _, err = io.ReadFull(r, headerBytes[:]); err != nil {
+		return
+	}
+
+	header := ParseHeader(headerBytes)
+	bodyBytes := make([]byte, header.CommandLength-16)
+	if _, err = io.ReadFull(r, bodyBytes); err != nil {
+		return
+	}
+
+	if pdu, err = CreatePDUFromCmdID(header.CommandID); err == nil {
+		buf := NewBuffer(make([]byte, 0, header.CommandLength))
+		_, _ = buf.Write(headerBytes[:])
+		_, _ = buf.Write(bodyBytes)
+		err = pdu.Unmarshal(buf)
+	}
+	return
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	p, err := Parse(bytes.NewReader(benchmarkSubmitSM()))
+	require.Nil(b, err)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := AcquireBuffer()
+		p.Marshal(buf)
+		ReleaseBuffer(buf)
+	}
+}
@@ -0,0 +1,99 @@
+package pdu
+
+import (
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/errors"
+)
+
+// QueryBroadcastSMResp PDU.
+//
+// Message state is carried by message_state TLV (TagMessageStateOption).
+type QueryBroadcastSMResp struct {
+	base
+	MessageID string
+
+	// Areas are broadcast_area_identifier TLVs of queried broadcast.
+	Areas []BroadcastArea
+
+	// AreaSuccess are broadcast_area_success TLVs, success rate (0-100, or 255 if unknown)
+	// of each area in the same order as Areas.
+	AreaSuccess []byte
+}
+
+// NewQueryBroadcastSMResp returns new QueryBroadcastSMResp.
+func NewQueryBroadcastSMResp() PDU {
+	c := &QueryBroadcastSMResp{
+		base:      newBase(),
+		MessageID: data.DFLT_MSGID,
+	}
+	c.CommandID = data.QUERY_BROADCAST_SM_RESP
+	return c
+}
+
+// NewQueryBroadcastSMRespFromReq returns new QueryBroadcastSMResp.
+func NewQueryBroadcastSMRespFromReq(req *QueryBroadcastSM) PDU {
+	c := NewQueryBroadcastSMResp().(*QueryBroadcastSMResp)
+	if req != nil {
+		c.SequenceNumber = req.SequenceNumber
+	}
+	return c
+}
+
+// CanResponse implements PDU interface.
+func (c *QueryBroadcastSMResp) CanResponse() bool {
+	return false
+}
+
+// GetResponse implements PDU interface.
+func (c *QueryBroadcastSMResp) GetResponse() PDU {
+	return nil
+}
+
+// Marshal implements PDU interface.
+func (c *QueryBroadcastSMResp) Marshal(b *ByteBuffer) {
+	c.base.marshal(b, func(b *ByteBuffer) {
+		b.Grow(len(c.MessageID) + 1 + 5*len(c.AreaSuccess))
+
+		_ = b.WriteCString(c.MessageID)
+		marshalBroadcastAreas(b, c.Areas)
+		for _, success := range c.AreaSuccess {
+			field := Field{Tag: TagBroadcastAreaSuccess, Data: []byte{success}}
+			field.Marshal(b)
+		}
+	})
+}
+
+// Unmarshal implements PDU interface.
+func (c *QueryBroadcastSMResp) Unmarshal(b *ByteBuffer) error {
+	return c.base.unmarshal(b, func(b *ByteBuffer) (err error) {
+		// body might be omitted if command status is not ok
+		if int(c.CommandLength) == data.PDU_HEADER_SIZE {
+			return
+		}
+
+		start := b.Len()
+		if c.MessageID, err = b.ReadCString(); err == nil {
+			err = c.unmarshalRepeatedParams(b, start-b.Len(), c.takeRepeated)
+		}
+		return
+	})
+}
+
+func (c *QueryBroadcastSMResp) takeRepeated(f Field) (bool, error) {
+	switch f.Tag {
+	case TagBroadcastAreaIdentifier:
+		area, err := parseBroadcastArea(f)
+		if err == nil {
+			c.Areas = append(c.Areas, area)
+		}
+		return true, err
+
+	case TagBroadcastAreaSuccess:
+		if len(f.Data) != 1 {
+			return true, errors.ErrInvalidPDU
+		}
+		c.AreaSuccess = append(c.AreaSuccess, f.Data[0])
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,34 @@
+package pdu
+
+import (
+	"testing"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBroadcastSMResp(t *testing.T) {
+	req := NewQueryBroadcastSM().(*QueryBroadcastSM)
+	req.SequenceNumber = 13
+
+	v := NewQueryBroadcastSMRespFromReq(req).(*QueryBroadcastSMResp)
+	require.False(t, v.CanResponse())
+	require.Nil(t, v.GetResponse())
+
+	v.MessageID = "foo"
+	v.Areas = []BroadcastArea{
+		{Format: data.BROADCAST_AREA_FORMAT_ALIAS, Details: []byte("north")},
+		{Format: data.BROADCAST_AREA_FORMAT_ALIAS, Details: []byte("south")},
+	}
+	v.AreaSuccess = []byte{100, 255}
+
+	validate(t,
+		v,
+		"0000003280000112000000000000000d666f6f0006060006006e6f7274680606000600736f757468060800016406080001ff",
+		data.QUERY_BROADCAST_SM_RESP,
+	)
+
+	_, err := Parse(NewBuffer(fromHex("0000001980000112000000000000000d0006080002ffff")))
+	require.NotNil(t, err)
+}
@@ -0,0 +1,32 @@
+package pdu
+
+import (
+	"testing"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBroadcastSM(t *testing.T) {
+	v := NewQueryBroadcastSM().(*QueryBroadcastSM)
+	require.True(t, v.CanResponse())
+	v.SequenceNumber = 13
+
+	validate(t,
+		v.GetResponse(),
+		"0000001180000112000000000000000d00",
+		data.QUERY_BROADCAST_SM_RESP,
+	)
+
+	v.MessageID = "foo"
+	_ = v.SourceAddr.SetAddress("Alicer")
+	v.SourceAddr.SetTon(28)
+	v.SourceAddr.SetNpi(29)
+
+	validate(t,
+		v,
+		"0000001d00000112000000000000000d666f6f001c1d416c6963657200",
+		data.QUERY_BROADCAST_SM,
+	)
+}
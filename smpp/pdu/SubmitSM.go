@@ -63,6 +63,8 @@ func (c *SubmitSM) GetResponse() PDU {
 // Each have the TPUD within the GSM's User Data limit of 140 octet
 // If the message is short enough and doesn't need splitting,
 // Split() returns an array of length 1
+//
+// Use ComposeMultipart to control reference number and segmentation method.
 func (c *SubmitSM) Split() (multiSubSM []*SubmitSM, err error) {
 	multiSubSM = []*SubmitSM{}
 
@@ -42,9 +42,12 @@ func (c *SubmitSMResp) GetResponse() PDU {
 // Marshal implements PDU interface.
 func (c *SubmitSMResp) Marshal(b *ByteBuffer) {
 	c.base.marshal(b, func(b *ByteBuffer) {
-		b.Grow(len(c.MessageID) + 1)
+		// body is not returned if command status is not ok
+		if c.CommandStatus == data.ESME_ROK {
+			b.Grow(len(c.MessageID) + 1)
 
-		_ = b.WriteCString(c.MessageID)
+			_ = b.WriteCString(c.MessageID)
+		}
 	})
 }
 
@@ -23,4 +23,13 @@ func TestSubmitSMResp(t *testing.T) {
 		"0000001980000004000000000000000d666f6f7462616c6c00",
 		data.SUBMIT_SM_RESP,
 	)
+
+	v.MessageID = ""
+	v.CommandStatus = data.ESME_RTHROTTLED
+
+	validate(t,
+		v,
+		"0000001080000004000000580000000d",
+		data.SUBMIT_SM_RESP,
+	)
 }
@@ -42,6 +42,7 @@ const (
 	TagLanguageIndicator        Tag = 0x020D
 	TagSarTotalSegments         Tag = 0x020E
 	TagSarSegmentSeqnum         Tag = 0x020F
+	TagScInterfaceVersion       Tag = 0x0210
 	TagCallbackNumPresInd       Tag = 0x0302
 	TagCallbackNumAtag          Tag = 0x0303
 	TagNumberOfMessages         Tag = 0x0304
@@ -63,6 +64,30 @@ const (
 	TagItsSessionInfo           Tag = 0x1383
 )
 
+// SMPP v5.0 Tag-Length-Value (TLV) tags.
+const (
+	TagCongestionState            Tag = 0x0428
+	TagBroadcastChannelIndicator  Tag = 0x0600
+	TagBroadcastContentType       Tag = 0x0601
+	TagBroadcastContentTypeInfo   Tag = 0x0602
+	TagBroadcastMessageClass      Tag = 0x0603
+	TagBroadcastRepNum            Tag = 0x0604
+	TagBroadcastFrequencyInterval Tag = 0x0605
+	TagBroadcastAreaIdentifier    Tag = 0x0606
+	TagBroadcastErrorStatus       Tag = 0x0607
+	TagBroadcastAreaSuccess       Tag = 0x0608
+	TagBroadcastEndTime           Tag = 0x0609
+	TagBroadcastServiceGroup      Tag = 0x060A
+	TagBillingIdentification      Tag = 0x060B
+	TagSourceNetworkID            Tag = 0x060D
+	TagDestNetworkID              Tag = 0x060E
+	TagSourceNodeID               Tag = 0x060F
+	TagDestNodeID                 Tag = 0x0610
+	TagDestAddrNpResolution       Tag = 0x0611
+	TagDestAddrNpInformation      Tag = 0x0612
+	TagDestAddrNpCountry          Tag = 0x0613
+)
+
 // Field is a PDU Tag-Length-Value (TLV) field
 type Field struct {
 	Tag  Tag
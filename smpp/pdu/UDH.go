@@ -165,6 +165,23 @@ func (u UDH) GetConcatInfo() (totalParts, partNum, mref byte, found bool) {
 	return
 }
 
+// GetConcatInfo16 return the FIRST concatenated message IE with 16-bit reference number.
+func (u UDH) GetConcatInfo16() (totalParts, partNum byte, mref uint16, found bool) {
+	if len(u) == 0 {
+		found = false
+		return
+	}
+
+	if ie, ok := u.FindInfoElement(data.UDH_CONCAT_MSG_16_BIT_REF); ok && len(ie.Data) == 4 {
+		mref = uint16(ie.Data[0])<<8 | uint16(ie.Data[1])
+		totalParts = ie.Data[2]
+		partNum = ie.Data[3]
+		found = ok
+	}
+
+	return
+}
+
 // InfoElement represent a 3 parts Information-Element
 // as defined in 3GPP TS 23.040 Section 9.2.3.24
 // Each InfoElement is comprised of it's identifier and data
@@ -182,6 +199,14 @@ func NewIEConcatMessage(totalParts, partNum, mref byte) InfoElement {
 	}
 }
 
+// NewIEConcatMessage16 returns a new IE for concat message info with 16-bit reference number.
+func NewIEConcatMessage16(totalParts, partNum byte, mref uint16) InfoElement {
+	return InfoElement{
+		ID:   data.UDH_CONCAT_MSG_16_BIT_REF,
+		Data: []byte{byte(mref >> 8), byte(mref), totalParts, partNum},
+	}
+}
+
 // UnmarshalBinary unmarshal IE from binary in src, only read a single IE,
 // expect src at least of length 2 with correct IE format:
 //		[ ID_1, LENGTH_1, DATA_N ]
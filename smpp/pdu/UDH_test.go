@@ -27,6 +27,22 @@ func TestUserDataHeader(t *testing.T) {
 		require.Equal(t, reference, uint8(12))
 	})
 
+	t.Run("unmarshalBinaryUDHConcatMessage (16 bit)", func(t *testing.T) {
+		u, rd := new(UDH), []byte{0x06, 0x08, 0x04, 0x12, 0x34, 0x03, 0x02}
+		read, err := u.UnmarshalBinary(rd)
+		require.NoError(t, err)
+		require.Equal(t, 7, read)
+
+		totalParts, sequence, reference, found := u.GetConcatInfo16()
+		require.True(t, found)
+		require.Equal(t, byte(3), totalParts)
+		require.Equal(t, byte(2), sequence)
+		require.Equal(t, uint16(0x1234), reference)
+
+		_, _, _, found = u.GetConcatInfo()
+		require.False(t, found)
+	})
+
 	t.Run("unmarshalBinaryUDHConcatMessage", func(t *testing.T) {
 		u, rd := new(UDH), []byte{0x05, 0x00, 0x03, 0x0c, 0x02, 0x01}
 		read, err := u.UnmarshalBinary(rd)
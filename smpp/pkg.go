@@ -6,6 +6,7 @@ import (
 	"io"
 	"time"
 
+	"github.com/sujit-baniya/protocol/smpp/data"
 	"github.com/sujit-baniya/protocol/smpp/pdu"
 )
 
@@ -17,6 +18,26 @@ type Transceiver interface {
 	SystemID() string
 }
 
+// AsyncSubmitter is Transceiver which also submits asynchronously and with context,
+// see Session.AsyncSubmitter. It is kept apart from Transceiver so that
+// existing implementations of Transceiver remain valid.
+type AsyncSubmitter interface {
+	Transceiver
+	SubmitAsync(pdu.PDU) <-chan Result
+	SubmitContext(context.Context, pdu.PDU) error
+	InterfaceVersion() byte
+}
+
+// Result of request submitted asynchronously.
+type Result struct {
+	Request pdu.PDU
+
+	// Response is nil if Err is not nil.
+	Response pdu.PDU
+
+	Err error
+}
+
 // Transmitter interface.
 type Transmitter interface {
 	io.Closer
@@ -42,6 +63,10 @@ type Settings struct {
 	// WriteTimeout is timeout for submitting PDU.
 	WriteTimeout time.Duration
 
+	// WriteBatch coalesces queued PDUs into fewer writes for high throughput binds.
+	// Nil writes each PDU on its own.
+	WriteBatch *WriteBatchSettings
+
 	// EnquireLink periodically sends EnquireLink to SMSC.
 	// The duration must not be smaller than 1 minute.
 	//
@@ -64,11 +89,92 @@ type Settings struct {
 	// OnRebindingError notifies error while rebinding.
 	OnRebindingError ErrorCallback
 
+	// RebindPolicy decides whether and when Session rebinds.
+	// Nil uses FixedRebindPolicy with `rebindingInterval` of NewSession.
+	// Non-nil policy enables rebinding regardless of `rebindingInterval`.
+	RebindPolicy RebindPolicy
+
+	// OnRebindAttempt notifies rebinding attempt (starting from 1) is about to dial SMSC.
+	OnRebindAttempt func(attempt int)
+
+	// OnRebound notifies session is bound again after the number of attempts and elapsed duration.
+	OnRebound func(attempts int, elapsed time.Duration)
+
+	// OnRebindGiveUp notifies RebindPolicy gave up after the number of failed attempts,
+	// along with the last error. The session is closed.
+	OnRebindGiveUp func(attempts int, err error)
+
 	// OnClosed notifies `closed` event due to State.
 	OnClosed ClosedCallback
 
+	// OnStateChange notifies transition of Session lifecycle, see SessionState.
+	OnStateChange SessionEventCallback
+
+	// Logger receives structured logs of binding, rebinding, enquire_link, submitting and receiving,
+	// e.g. *slog.Logger or NewStdLogger. Nil disables logging.
+	Logger Logger
+
+	// LogHexDump adds hex dump of submitted and received PDUs to debug logs.
+	LogHexDump bool
+
+	// Metrics receives instrumentation. Nil collects metrics of Session into MemoryMetrics,
+	// see Session.Metrics. NopMetrics disables instrumentation.
+	Metrics Metrics
+
+	// Tracer traces each SubmitSM from submission until its response, and delivery receipts
+	// linked to them by message_id, e.g. MemoryTracer. Nil disables tracing.
+	Tracer Tracer
+
+	// WindowSize is maximum number of requests waiting for response per bind,
+	// submitted via SubmitAsync or SubmitResp.
+	//
+	// Zero disables windowing.
+	WindowSize int
+
+	// ResponseTimeout is time to wait for response of submitted request.
+	// Request submitted via SubmitAsync or SubmitResp expires with ErrResponseTimeout.
+	//
+	// Zero waits until transceiver is closed, but request submitted via Submit
+	// is tracked for DefaultSubmitTracking at most.
+	ResponseTimeout time.Duration
+
+	// ResponseTimeouts overrides ResponseTimeout per command_id of request.
+	ResponseTimeouts map[data.CommandIDType]time.Duration
+
+	// OnResponseTimeout notifies request expired without response.
+	OnResponseTimeout func(req pdu.PDU)
+
+	// MaxResponseTimeouts is number of consecutive response timeouts after which
+	// the connection is unbound and closed with UnresponsiveClosing state.
+	//
+	// Zero disables this policy.
+	MaxResponseTimeouts int
+
+	// OnUnmatchedResponse handles response which does not match any submitted request.
+	// Such response is not passed to OnPDU.
+	OnUnmatchedResponse func(resp pdu.PDU)
+
+	// SequenceGenerator numbers requests sent by Session, across rebinds.
+	// Nil uses SequenceCounter starting from MinSequenceNumber.
+	SequenceGenerator SequenceGenerator
+
 	RateLimiter *rate.Limiter
 
-	response func(pdu.PDU)
-	Throttle int
+	// Limiter is consulted before each PDU is submitted, on top of RateLimiter.
+	// It may be shared by sessions, see QuotaLimiter. Nil disables it.
+	Limiter Limiter
+
+	// AdaptiveRate adjusts rate of messages by throttling responses of SMSC. Nil disables it.
+	AdaptiveRate *AdaptiveRateSettings
+
+	// OutboundBuffer enables store-and-forward queue for Session.Submit, holding PDUs
+	// while rebinding. Nil submits to bound transceiver directly.
+	OutboundBuffer *OutboundBufferSettings
+
+	response  func(pdu.PDU)
+	written   func(sequence int32)
+	sessionID string        // labels metrics
+	receipts  *receiptLinks // links delivery receipts to traced requests
+	adaptive  *adaptiveRate // keeps adaptive rate across rebinds
+	Throttle  int
 }
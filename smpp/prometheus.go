@@ -0,0 +1,161 @@
+package smpp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is content type of Prometheus text exposition format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusHandler exposes MemoryMetrics in Prometheus text exposition format.
+func PrometheusHandler(m *MemoryMetrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", PrometheusContentType)
+		_ = WritePrometheus(w, m.Snapshot())
+	})
+}
+
+// WritePrometheus writes snapshot in Prometheus text exposition format.
+func WritePrometheus(w io.Writer, s MetricsSnapshot) error {
+	p := &promWriter{w: bufio.NewWriter(w)}
+
+	p.counters("smpp_pdus_sent_total", "PDUs written to SMSC.", s.Sent, commandLabels)
+	p.counters("smpp_pdus_received_total", "PDUs read from SMSC.", s.Received, commandLabels)
+	p.counters("smpp_responses_total", "Responses received from SMSC by command status.", s.Responses, func(k MetricKey) []string {
+		return append(commandLabels(k), "command_status", k.Status.String())
+	})
+	p.histograms("smpp_response_latency_seconds", "Duration from submitting request until its response arrives.", s.Latency, commandLabels)
+
+	p.header("smpp_window_occupancy", "Requests holding the window.", "gauge")
+	sessions := make([]string, 0, len(s.Window))
+	for session := range s.Window {
+		sessions = append(sessions, session)
+	}
+	sort.Strings(sessions)
+	for _, session := range sessions {
+		p.sample("smpp_window_occupancy", []string{"session", session}, strconv.Itoa(s.Window[session]))
+	}
+
+	p.counters("smpp_rebind_attempts_total", "Rebinding attempts by result.", s.Rebinds, func(k MetricKey) []string {
+		result := "failure"
+		if k.Success {
+			result = "success"
+		}
+		return append(sessionLabels(k), "result", result)
+	})
+	p.histograms("smpp_enquire_link_rtt_seconds", "Round trip time of enquire_link.", s.EnquireLinkRTT, sessionLabels)
+	p.histograms("smpp_limiter_wait_seconds", "Time spent waiting for rate limiter.", s.LimiterWait, sessionLabels)
+
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+func sessionLabels(k MetricKey) []string {
+	return []string{"session", k.Session}
+}
+
+func commandLabels(k MetricKey) []string {
+	return []string{"session", k.Session, "command_id", k.CommandID.String()}
+}
+
+type promWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (p *promWriter) printf(format string, args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+func (p *promWriter) header(name, help, kind string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p *promWriter) sample(name string, labels []string, value string) {
+	p.printf("%s%s %s\n", name, formatLabels(labels), value)
+}
+
+func (p *promWriter) counters(name, help string, counters map[MetricKey]uint64, labels func(MetricKey) []string) {
+	p.header(name, help, "counter")
+	keys := make([]MetricKey, 0, len(counters))
+	for k := range counters {
+		keys = append(keys, k)
+	}
+
+	for _, series := range sortedSeries(keys, labels) {
+		p.sample(name, series.labels, strconv.FormatUint(counters[series.key], 10))
+	}
+}
+
+func (p *promWriter) histograms(name, help string, histograms map[MetricKey]Histogram, labels func(MetricKey) []string) {
+	p.header(name, help, "histogram")
+	keys := make([]MetricKey, 0, len(histograms))
+	for k := range histograms {
+		keys = append(keys, k)
+	}
+
+	for _, series := range sortedSeries(keys, labels) {
+		h := histograms[series.key]
+		for i, bound := range h.Buckets {
+			p.sample(name+"_bucket", append(series.labels, "le", formatFloat(bound)), strconv.FormatUint(h.Counts[i], 10))
+		}
+		p.sample(name+"_bucket", append(series.labels, "le", "+Inf"), strconv.FormatUint(h.Count, 10))
+		p.sample(name+"_sum", series.labels, formatFloat(h.Sum))
+		p.sample(name+"_count", series.labels, strconv.FormatUint(h.Count, 10))
+	}
+}
+
+type promSeries struct {
+	key    MetricKey
+	labels []string
+	id     string
+}
+
+// sortedSeries orders series by their labels, for stable output.
+func sortedSeries(keys []MetricKey, labels func(MetricKey) []string) []promSeries {
+	series := make([]promSeries, 0, len(keys))
+	for _, k := range keys {
+		l := labels(k)
+		series = append(series, promSeries{key: k, labels: l[:len(l):len(l)], id: formatLabels(l)})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].id < series[j].id
+	})
+	return series
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(labels[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,60 @@
+package smpp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	m := NewMemoryMetrics([]float64{0.1, 1})
+	m.PDUSent("b", data.SUBMIT_SM)
+	m.PDUSent("a", data.SUBMIT_SM)
+	m.ResponseStatus("a", data.SUBMIT_SM_RESP, data.ESME_ROK)
+	m.ResponseLatency("a", data.SUBMIT_SM, 250*time.Millisecond)
+	m.WindowOccupancy(`a"b`, 2)
+	m.RebindAttempt("a", nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePrometheus(&buf, m.Snapshot()))
+	out := buf.String()
+
+	require.Contains(t, out, "# TYPE smpp_pdus_sent_total counter\n"+
+		`smpp_pdus_sent_total{session="a",command_id="SUBMIT_SM"} 1`+"\n"+
+		`smpp_pdus_sent_total{session="b",command_id="SUBMIT_SM"} 1`+"\n")
+	require.Contains(t, out, `smpp_responses_total{session="a",command_id="SUBMIT_SM_RESP",command_status="ESME_ROK"} 1`)
+	require.Contains(t, out, "# TYPE smpp_response_latency_seconds histogram\n"+
+		`smpp_response_latency_seconds_bucket{session="a",command_id="SUBMIT_SM",le="0.1"} 0`+"\n"+
+		`smpp_response_latency_seconds_bucket{session="a",command_id="SUBMIT_SM",le="1"} 1`+"\n"+
+		`smpp_response_latency_seconds_bucket{session="a",command_id="SUBMIT_SM",le="+Inf"} 1`+"\n"+
+		`smpp_response_latency_seconds_sum{session="a",command_id="SUBMIT_SM"} 0.25`+"\n"+
+		`smpp_response_latency_seconds_count{session="a",command_id="SUBMIT_SM"} 1`+"\n")
+	require.Contains(t, out, `smpp_window_occupancy{session="a\"b"} 2`)
+	require.Contains(t, out, `smpp_rebind_attempts_total{session="a",result="success"} 1`)
+	require.Contains(t, out, "# TYPE smpp_limiter_wait_seconds histogram\n")
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	m := NewMemoryMetrics(nil)
+	m.PDUReceived("a", data.DELIVER_SM)
+
+	srv := httptest.NewServer(PrometheusHandler(m))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, PrometheusContentType, resp.Header.Get("Content-Type"))
+	require.True(t, strings.Contains(string(body), `smpp_pdus_received_total{session="a",command_id="DELIVER_SM"} 1`))
+}
@@ -0,0 +1,355 @@
+package smpp
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrProxyAuthRejected indicates proxy rejected credentials or requires them.
+	ErrProxyAuthRejected = fmt.Errorf("proxy: authentication rejected")
+
+	// ErrNoInterfaceAddr indicates network interface has no address to bind local side of connection to.
+	ErrNoInterfaceAddr = fmt.Errorf("network interface has no address")
+)
+
+// NetConfig for NetDialer.
+type NetConfig struct {
+	// LocalAddr is local IP address, optionally with port, which connections are bound to,
+	// e.g. source IP whitelisted by SMSC.
+	LocalAddr string
+
+	// Interface binds connections to the first address of the named network interface, e.g. "eth1",
+	// preferring IPv4. Ignored if LocalAddr is set.
+	Interface string
+
+	// DialTimeout is timeout for establishing connection.
+	DialTimeout time.Duration
+
+	// KeepAlive is period of TCP keep-alive probes, see net.Dialer.
+	KeepAlive time.Duration
+}
+
+// NetDialer returns dialer establishing TCP connection from selected local address.
+func NetDialer(config NetConfig) Dialer {
+	return func(addr string) (net.Conn, error) {
+		local, err := config.localAddr()
+		if err != nil {
+			return nil, err
+		}
+
+		d := &net.Dialer{
+			Timeout:   config.DialTimeout,
+			KeepAlive: config.KeepAlive,
+		}
+		if local != nil {
+			d.LocalAddr = local
+		}
+		return d.Dial("tcp", addr)
+	}
+}
+
+func (c *NetConfig) localAddr() (*net.TCPAddr, error) {
+	if c.LocalAddr != "" {
+		host, port := c.LocalAddr, "0"
+		if h, p, err := net.SplitHostPort(c.LocalAddr); err == nil {
+			host, port = h, p
+		}
+		return net.ResolveTCPAddr("tcp", net.JoinHostPort(host, port))
+	}
+
+	if c.Interface == "" {
+		return nil, nil
+	}
+
+	iface, err := net.InterfaceByName(c.Interface)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+
+	var ipv6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ip := ipNet.IP.To4(); ip != nil {
+			return &net.TCPAddr{IP: ip}, nil
+		}
+		if ipv6 == nil && !ipNet.IP.IsLinkLocalUnicast() {
+			ipv6 = ipNet.IP
+		}
+	}
+	if ipv6 != nil {
+		return &net.TCPAddr{IP: ipv6}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoInterfaceAddr, c.Interface)
+}
+
+// ProxyConfig for SOCKS5Dialer and HTTPProxyDialer.
+type ProxyConfig struct {
+	// Proxy is proxy address, e.g. "proxy.internal:1080".
+	Proxy string
+
+	// Username and Password authenticate to proxy. Empty Username skips authentication.
+	Username string
+	Password string
+
+	// HandshakeTimeout is timeout for negotiating tunnel with proxy. Zero means no timeout.
+	HandshakeTimeout time.Duration
+
+	// Dialer dials proxy. Nil dials TCP, see NetDialer for local address selection.
+	Dialer Dialer
+}
+
+func (c *ProxyConfig) dialProxy() (net.Conn, error) {
+	dial := c.Dialer
+	if dial == nil {
+		dial = NonTLSDialer
+	}
+	return dial(c.Proxy)
+}
+
+// tunnel dials proxy and negotiates tunnel to addr within HandshakeTimeout.
+func (c *ProxyConfig) tunnel(addr string, handshake func(conn net.Conn, addr string) (net.Conn, error)) (net.Conn, error) {
+	conn, err := c.dialProxy()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.HandshakeTimeout > 0 {
+		if err = conn.SetDeadline(time.Now().Add(c.HandshakeTimeout)); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	tunnel, err := handshake(conn, addr)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if err = conn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return tunnel, nil
+}
+
+// SOCKS5Dialer returns dialer tunneling connections through SOCKS5 proxy (RFC 1928),
+// authenticating with username and password (RFC 1929) if set.
+// SMSC host name is resolved by proxy.
+//
+// TLS connection is established over the tunnel with TLSConfig.Dialer.
+func SOCKS5Dialer(config ProxyConfig) Dialer {
+	return func(addr string) (net.Conn, error) {
+		return config.tunnel(addr, config.socks5)
+	}
+}
+
+const (
+	socks5Version         = 5
+	socks5AuthNone        = 0
+	socks5AuthPassword    = 2
+	socks5NoAcceptable    = 0xff
+	socks5Connect         = 1
+	socks5AddrIPv4        = 1
+	socks5AddrDomain      = 3
+	socks5AddrIPv6        = 4
+	socks5PasswordVersion = 1
+)
+
+var socks5Replies = []string{
+	"succeeded",
+	"general SOCKS server failure",
+	"connection not allowed by ruleset",
+	"network unreachable",
+	"host unreachable",
+	"connection refused",
+	"TTL expired",
+	"command not supported",
+	"address type not supported",
+}
+
+func (c *ProxyConfig) socks5(conn net.Conn, addr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("socks5: invalid port %q", portStr)
+	}
+
+	// greeting
+	method := byte(socks5AuthNone)
+	if c.Username != "" {
+		method = socks5AuthPassword
+	}
+	if _, err = conn.Write([]byte{socks5Version, 1, method}); err != nil {
+		return nil, err
+	}
+
+	var reply [2]byte
+	if _, err = io.ReadFull(conn, reply[:]); err != nil {
+		return nil, err
+	}
+	if reply[0] != socks5Version {
+		return nil, fmt.Errorf("socks5: unexpected version %d", reply[0])
+	}
+	if reply[1] == socks5NoAcceptable || reply[1] != method {
+		return nil, ErrProxyAuthRejected
+	}
+
+	if method == socks5AuthPassword {
+		if len(c.Username) > 255 || len(c.Password) > 255 {
+			return nil, fmt.Errorf("socks5: username or password is too long")
+		}
+
+		req := make([]byte, 0, 3+len(c.Username)+len(c.Password))
+		req = append(req, socks5PasswordVersion, byte(len(c.Username)))
+		req = append(req, c.Username...)
+		req = append(req, byte(len(c.Password)))
+		req = append(req, c.Password...)
+		if _, err = conn.Write(req); err != nil {
+			return nil, err
+		}
+
+		if _, err = io.ReadFull(conn, reply[:]); err != nil {
+			return nil, err
+		}
+		if reply[1] != 0 {
+			return nil, ErrProxyAuthRejected
+		}
+	}
+
+	// connect
+	req := []byte{socks5Version, socks5Connect, 0}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return nil, fmt.Errorf("socks5: host name is too long")
+		}
+		req = append(req, socks5AddrDomain, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, socks5AddrIPv4)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, socks5AddrIPv6)
+		req = append(req, ip...)
+	}
+	req = append(req, byte(port>>8), byte(port))
+	if _, err = conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	var header [4]byte
+	if _, err = io.ReadFull(conn, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0] != socks5Version {
+		return nil, fmt.Errorf("socks5: unexpected version %d", header[0])
+	}
+	if status := header[1]; status != 0 {
+		if int(status) < len(socks5Replies) {
+			return nil, fmt.Errorf("socks5: %s", socks5Replies[status])
+		}
+		return nil, fmt.Errorf("socks5: connect failed with reply %d", status)
+	}
+
+	// skip bound address
+	var skip int
+	switch header[3] {
+	case socks5AddrIPv4:
+		skip = net.IPv4len
+	case socks5AddrIPv6:
+		skip = net.IPv6len
+	case socks5AddrDomain:
+		var length [1]byte
+		if _, err = io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		skip = int(length[0])
+	default:
+		return nil, fmt.Errorf("socks5: unknown address type %d", header[3])
+	}
+
+	if _, err = io.ReadFull(conn, make([]byte, skip+2)); err != nil {
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// HTTPProxyDialer returns dialer tunneling connections through HTTP proxy with CONNECT method,
+// authenticating with basic scheme if Username is set.
+//
+// Proxy serving HTTPS is reached with ProxyConfig.Dialer set to TLSDialer.
+// TLS connection to SMSC is established over the tunnel with TLSConfig.Dialer.
+func HTTPProxyDialer(config ProxyConfig) Dialer {
+	return func(addr string) (net.Conn, error) {
+		return config.tunnel(addr, config.httpConnect)
+	}
+}
+
+func (c *ProxyConfig) httpConnect(conn net.Conn, addr string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if c.Username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	// body of response is not read, connection is closed on failure anyway
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusProxyAuthRequired:
+		return nil, ErrProxyAuthRejected
+
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("http proxy: CONNECT %s: %s", addr, resp.Status)
+	}
+
+	// SMSC might have spoken already
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads bytes buffered while negotiating tunnel before the rest of connection.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,304 @@
+package smpp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestProxy negotiates tunnel with each accepted connection by handshake
+// and forwards the tunnel to upstream.
+func newTestProxy(t *testing.T, handshake func(conn net.Conn) (net.Conn, bool), upstream Dialer) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer func() {
+					_ = conn.Close()
+				}()
+
+				tunnel, ok := handshake(conn)
+				if !ok {
+					return
+				}
+
+				remote, err := upstream("")
+				if err != nil {
+					return
+				}
+				defer func() {
+					_ = remote.Close()
+				}()
+
+				go func() {
+					_, _ = io.Copy(remote, tunnel)
+					_ = remote.Close()
+				}()
+				_, _ = io.Copy(conn, remote)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+// socks5Handshake serves SOCKS5 handshake, requiring credentials if username is set.
+// Requested target is sent to targets.
+func socks5Handshake(username, password string, reply byte, targets chan<- string) func(net.Conn) (net.Conn, bool) {
+	return func(conn net.Conn) (net.Conn, bool) {
+		buf := make([]byte, 512)
+		if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+			return nil, false
+		}
+		methods := buf[2 : 2+buf[1]]
+		if _, err := io.ReadFull(conn, methods); err != nil {
+			return nil, false
+		}
+
+		want := byte(socks5AuthNone)
+		if username != "" {
+			want = socks5AuthPassword
+		}
+		offered := false
+		for _, m := range methods {
+			offered = offered || m == want
+		}
+		if !offered {
+			_, _ = conn.Write([]byte{socks5Version, socks5NoAcceptable})
+			return nil, false
+		}
+		_, _ = conn.Write([]byte{socks5Version, want})
+
+		if username != "" {
+			_, _ = io.ReadFull(conn, buf[:2])
+			user := make([]byte, buf[1])
+			_, _ = io.ReadFull(conn, user)
+			_, _ = io.ReadFull(conn, buf[:1])
+			pass := make([]byte, buf[0])
+			_, _ = io.ReadFull(conn, pass)
+
+			if string(user) != username || string(pass) != password {
+				_, _ = conn.Write([]byte{socks5PasswordVersion, 1})
+				return nil, false
+			}
+			_, _ = conn.Write([]byte{socks5PasswordVersion, 0})
+		}
+
+		if _, err := io.ReadFull(conn, buf[:4]); err != nil || buf[1] != socks5Connect {
+			return nil, false
+		}
+
+		var host string
+		switch buf[3] {
+		case socks5AddrIPv4:
+			ip := make([]byte, net.IPv4len)
+			_, _ = io.ReadFull(conn, ip)
+			host = net.IP(ip).String()
+		case socks5AddrIPv6:
+			ip := make([]byte, net.IPv6len)
+			_, _ = io.ReadFull(conn, ip)
+			host = net.IP(ip).String()
+		case socks5AddrDomain:
+			_, _ = io.ReadFull(conn, buf[:1])
+			name := make([]byte, buf[0])
+			_, _ = io.ReadFull(conn, name)
+			host = string(name)
+		}
+		_, _ = io.ReadFull(conn, buf[:2])
+		targets <- net.JoinHostPort(host, strconv.Itoa(int(buf[0])<<8|int(buf[1])))
+
+		_, _ = conn.Write([]byte{socks5Version, reply, 0, socks5AddrIPv4, 127, 0, 0, 1, 0, 0})
+		return conn, reply == 0
+	}
+}
+
+// httpConnectHandshake serves CONNECT request, requiring basic credentials if username is set.
+func httpConnectHandshake(username, password string, targets chan<- string) func(net.Conn) (net.Conn, bool) {
+	return func(conn net.Conn) (net.Conn, bool) {
+		reader := bufio.NewReader(conn)
+		req, err := http.ReadRequest(reader)
+		if err != nil || req.Method != http.MethodConnect {
+			return nil, false
+		}
+		targets <- req.Host
+
+		if username != "" {
+			credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+			if req.Header.Get("Proxy-Authorization") != "Basic "+credentials {
+				_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+				return nil, false
+			}
+		}
+
+		if req.Host == "forbidden:2775" {
+			_, _ = io.WriteString(conn, "HTTP/1.1 403 Forbidden\r\n\r\n")
+			return nil, false
+		}
+
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		return &bufferedConn{Conn: conn, reader: reader}, true
+	}
+}
+
+func bindVia(t *testing.T, dialer Dialer, addr string) error {
+	conn, err := TRXConnector(dialer, Auth{SMSC: addr}).Connect()
+	if err == nil {
+		require.Equal(t, smsctest.DefaultSystemID, conn.systemID)
+		_ = conn.Close()
+	}
+	return err
+}
+
+func TestSOCKS5Dialer(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	targets := make(chan string, 10)
+
+	t.Run("no auth", func(t *testing.T) {
+		proxy := newTestProxy(t, socks5Handshake("", "", 0, targets), smsc.Dial)
+		require.NoError(t, bindVia(t, SOCKS5Dialer(ProxyConfig{Proxy: proxy}), "smsc.test:2775"))
+		require.Equal(t, "smsc.test:2775", <-targets)
+
+		require.NoError(t, bindVia(t, SOCKS5Dialer(ProxyConfig{Proxy: proxy}), "10.0.0.1:2775"))
+		require.Equal(t, "10.0.0.1:2775", <-targets)
+
+		require.NoError(t, bindVia(t, SOCKS5Dialer(ProxyConfig{Proxy: proxy}), "[2001:db8::1]:2775"))
+		require.Equal(t, "[2001:db8::1]:2775", <-targets)
+	})
+
+	t.Run("auth", func(t *testing.T) {
+		proxy := newTestProxy(t, socks5Handshake("esme", "secret", 0, targets), smsc.Dial)
+		require.NoError(t, bindVia(t, SOCKS5Dialer(ProxyConfig{
+			Proxy:    proxy,
+			Username: "esme",
+			Password: "secret",
+		}), "smsc.test:2775"))
+		<-targets
+
+		err := bindVia(t, SOCKS5Dialer(ProxyConfig{
+			Proxy:    proxy,
+			Username: "esme",
+			Password: "wrong",
+		}), "smsc.test:2775")
+		require.ErrorIs(t, err, ErrProxyAuthRejected)
+
+		err = bindVia(t, SOCKS5Dialer(ProxyConfig{Proxy: proxy}), "smsc.test:2775")
+		require.ErrorIs(t, err, ErrProxyAuthRejected)
+	})
+
+	t.Run("connect refused", func(t *testing.T) {
+		proxy := newTestProxy(t, socks5Handshake("", "", 5, targets), smsc.Dial)
+		err := bindVia(t, SOCKS5Dialer(ProxyConfig{Proxy: proxy}), "smsc.test:2775")
+		require.EqualError(t, err, "socks5: connection refused")
+		<-targets
+	})
+
+	t.Run("TLS over tunnel", func(t *testing.T) {
+		serverCert, serverX509 := newTestCertificate(t, "smsc.test")
+		roots := x509.NewCertPool()
+		roots.AddCert(serverX509)
+
+		tlsAddr := newTLSProxy(t, smsc, &tls.Config{Certificates: []tls.Certificate{serverCert}})
+		proxy := newTestProxy(t, socks5Handshake("", "", 0, targets), func(string) (net.Conn, error) {
+			return net.Dial("tcp", tlsAddr)
+		})
+
+		require.NoError(t, bindVia(t, TLSDialer(TLSConfig{
+			Config: &tls.Config{RootCAs: roots},
+			Dialer: SOCKS5Dialer(ProxyConfig{Proxy: proxy}),
+		}), "smsc.test:2775"))
+		require.Equal(t, "smsc.test:2775", <-targets)
+	})
+}
+
+func TestHTTPProxyDialer(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	targets := make(chan string, 10)
+	proxy := newTestProxy(t, httpConnectHandshake("esme", "secret", targets), smsc.Dial)
+
+	config := ProxyConfig{Proxy: proxy, Username: "esme", Password: "secret"}
+	require.NoError(t, bindVia(t, HTTPProxyDialer(config), "smsc.test:2775"))
+	require.Equal(t, "smsc.test:2775", <-targets)
+
+	err := bindVia(t, HTTPProxyDialer(config), "forbidden:2775")
+	require.EqualError(t, err, "http proxy: CONNECT forbidden:2775: 403 Forbidden")
+	<-targets
+
+	config.Password = "wrong"
+	err = bindVia(t, HTTPProxyDialer(config), "smsc.test:2775")
+	require.ErrorIs(t, err, ErrProxyAuthRejected)
+	<-targets
+}
+
+func TestNetDialer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		_ = l.Close()
+	}()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	conn, err := NetDialer(NetConfig{LocalAddr: "127.0.0.1"})(l.Addr().String())
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", conn.LocalAddr().(*net.TCPAddr).IP.String())
+	_ = conn.Close()
+
+	_, err = NetDialer(NetConfig{Interface: "no-such-interface"})(l.Addr().String())
+	require.Error(t, err)
+
+	if loopback := loopbackInterface(); loopback != "" {
+		conn, err = NetDialer(NetConfig{Interface: loopback})(l.Addr().String())
+		require.NoError(t, err)
+		require.True(t, conn.LocalAddr().(*net.TCPAddr).IP.IsLoopback())
+		_ = conn.Close()
+	}
+}
+
+// loopbackInterface returns name of loopback interface with IPv4 address if any.
+func loopbackInterface() string {
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				return iface.Name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package smpp
+
+// raceEnabled reports whether tests run with race detector, which allocates on its own.
+const raceEnabled = true
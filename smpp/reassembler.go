@@ -0,0 +1,435 @@
+package smpp
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+// DefaultReassemblyTTL is default time to live of incomplete concatenated message.
+const DefaultReassemblyTTL = 5 * time.Minute
+
+var (
+	// ErrInvalidSegment indicates concatenated segment whose seq is not within [1, total].
+	ErrInvalidSegment = fmt.Errorf("segment seq is out of range of total segments")
+
+	// ErrSegmentConflict indicates segment whose total differs from segments received earlier.
+	ErrSegmentConflict = fmt.Errorf("segment total conflicts with earlier segments")
+)
+
+// SegmentRefKind tells which concatenation scheme carries Segment.Ref.
+type SegmentRefKind byte
+
+const (
+	// SegmentRef8Bit is reference of concatenation UDH IE with 8-bit reference.
+	SegmentRef8Bit SegmentRefKind = iota + 1
+	// SegmentRef16Bit is reference of concatenation UDH IE with 16-bit reference.
+	SegmentRef16Bit
+	// SegmentRefSAR is reference of sar_msg_ref_num TLV.
+	SegmentRefSAR
+)
+
+// Segment is one part of concatenated inbound message.
+type Segment struct {
+	SourceAddr string
+	DestAddr   string
+	Ref        uint16
+	RefKind    SegmentRefKind
+	Total      byte
+	Seq        byte
+	DataCoding byte
+	Data       []byte
+	Received   time.Time
+
+	// UnknownCoding marks segment whose data coding is not supported, DataCoding is meaningless then.
+	UnknownCoding bool
+}
+
+// InboundMessage is concatenated inbound message after reassembly.
+type InboundMessage struct {
+	SourceAddr string
+	DestAddr   string
+	Ref        uint16
+	Parts      int
+	DataCoding byte
+
+	// UnknownCoding marks message whose data coding is not supported.
+	UnknownCoding bool
+
+	// Data is concatenated user data, without UDH.
+	Data []byte
+
+	// Text is Data decoded with DataCoding. Empty if data coding is unknown or decoding failed.
+	Text string
+}
+
+// SegmentStore keeps segments of incomplete concatenated messages.
+// Implementations backed by persistent storage allow reassembly to survive restarts.
+type SegmentStore interface {
+	// Put stores segment under key, replacing segment with the same Seq,
+	// and returns number of distinct segments stored under key.
+	// Segment whose Total differs from segments stored under key is rejected with ErrSegmentConflict.
+	Put(key string, seg Segment) (count int, err error)
+
+	// Take removes and returns all segments stored under key.
+	Take(key string) ([]Segment, error)
+
+	// Expire removes and returns groups whose first segment was received before deadline.
+	Expire(deadline time.Time) (map[string][]Segment, error)
+}
+
+// ReassemblerSettings for Reassembler.
+type ReassemblerSettings struct {
+	// TTL is time to live of incomplete concatenated message.
+	// Zero uses DefaultReassemblyTTL.
+	TTL time.Duration
+
+	// Store keeps incomplete concatenated messages. Nil uses in-memory store.
+	Store SegmentStore
+
+	// OnMessage handles reassembled message.
+	// Single-part messages are reported as well, so every inbound message is delivered here.
+	OnMessage func(InboundMessage)
+
+	// OnExpired notifies incomplete concatenated message which is dropped after TTL.
+	OnExpired func(key string, segments []Segment)
+
+	// OnError notifies error happened while storing segments.
+	OnError ErrorCallback
+}
+
+// Reassembler buffers parts of concatenated DeliverSM/DataSM and emits
+// complete messages.
+//
+// Concatenation info is read from 8-bit and 16-bit UDH concatenation IEs or
+// from sar_msg_ref_num, sar_total_segments and sar_segment_seqnum TLVs.
+type Reassembler struct {
+	settings ReassemblerSettings
+	done     chan struct{}
+	once     sync.Once
+}
+
+// NewReassembler creates Reassembler and starts expiring incomplete messages.
+// Call Close to stop.
+func NewReassembler(settings ReassemblerSettings) *Reassembler {
+	if settings.TTL <= 0 {
+		settings.TTL = DefaultReassemblyTTL
+	}
+	if settings.Store == nil {
+		settings.Store = NewMemorySegmentStore()
+	}
+
+	r := &Reassembler{
+		settings: settings,
+		done:     make(chan struct{}),
+	}
+	go r.loop()
+
+	return r
+}
+
+// OnPDU wraps PDU callback to be used as Settings.OnPDU.
+// Every PDU is still passed to next, which keeps responsibility to respond.
+func (r *Reassembler) OnPDU(next PDUCallback) PDUCallback {
+	return func(p pdu.PDU, responded bool) {
+		if err := r.Handle(p); err != nil && r.settings.OnError != nil {
+			r.settings.OnError(err)
+		}
+
+		if next != nil {
+			next(p, responded)
+		}
+	}
+}
+
+// Handle DeliverSM/DataSM. Other PDUs and delivery receipts are ignored.
+func (r *Reassembler) Handle(p pdu.PDU) (err error) {
+	seg, concatenated, ok := segmentOf(p)
+	if !ok {
+		return
+	}
+
+	if !concatenated || seg.Total <= 1 {
+		r.emit([]Segment{seg})
+		return
+	}
+
+	if seg.Seq < 1 || seg.Seq > seg.Total {
+		return fmt.Errorf("%w: seq %d of %d", ErrInvalidSegment, seg.Seq, seg.Total)
+	}
+
+	key := segmentKey(seg)
+
+	count, err := r.settings.Store.Put(key, seg)
+	if err != nil || count < int(seg.Total) {
+		return
+	}
+
+	segments, err := r.settings.Store.Take(key)
+	if err != nil || len(segments) == 0 {
+		return
+	}
+
+	if !completeSegments(segments) {
+		return fmt.Errorf("%w: %s", ErrSegmentConflict, key)
+	}
+	r.emit(segments)
+	return
+}
+
+// completeSegments returns true if segments are exactly parts 1..Total of one message.
+func completeSegments(segments []Segment) bool {
+	total := segments[0].Total
+	if len(segments) != int(total) {
+		return false
+	}
+
+	seen := make([]bool, int(total)+1)
+	for _, seg := range segments {
+		if seg.Total != total || seg.Seq < 1 || seg.Seq > total || seen[seg.Seq] {
+			return false
+		}
+		seen[seg.Seq] = true
+	}
+	return true
+}
+
+// Close stops expiring incomplete messages.
+func (r *Reassembler) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+	})
+	return nil
+}
+
+func (r *Reassembler) loop() {
+	interval := r.settings.TTL / 10
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+
+		case <-ticker.C:
+			r.expire()
+		}
+	}
+}
+
+func (r *Reassembler) expire() {
+	expired, err := r.settings.Store.Expire(time.Now().Add(-r.settings.TTL))
+	if err != nil {
+		if r.settings.OnError != nil {
+			r.settings.OnError(err)
+		}
+		return
+	}
+
+	if r.settings.OnExpired != nil {
+		for key, segments := range expired {
+			r.settings.OnExpired(key, segments)
+		}
+	}
+}
+
+func (r *Reassembler) emit(segments []Segment) {
+	if r.settings.OnMessage == nil {
+		return
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Seq < segments[j].Seq
+	})
+
+	first := segments[0]
+	msg := InboundMessage{
+		SourceAddr: first.SourceAddr,
+		DestAddr:   first.DestAddr,
+		Ref:        first.Ref,
+		Parts:      len(segments),
+		DataCoding: first.DataCoding,
+	}
+	for _, seg := range segments {
+		msg.Data = append(msg.Data, seg.Data...)
+		msg.UnknownCoding = msg.UnknownCoding || seg.UnknownCoding
+	}
+
+	if !msg.UnknownCoding {
+		if enc := coding.FromDataCoding(msg.DataCoding); enc != nil {
+			msg.Text, _ = enc.Decode(msg.Data)
+		} else {
+			msg.UnknownCoding = true
+		}
+	}
+
+	r.settings.OnMessage(msg)
+}
+
+// segmentKey groups segments of one message. Total is not part of the key,
+// so that segment with conflicting total is detected instead of starting another group.
+// Ref kind is, since equal references of different schemes belong to different messages.
+func segmentKey(seg Segment) string {
+	return fmt.Sprintf("%s|%s|%d|%d", seg.SourceAddr, seg.DestAddr, seg.RefKind, seg.Ref)
+}
+
+// segmentOf extracts segment from DeliverSM/DataSM.
+func segmentOf(p pdu.PDU) (seg Segment, concatenated, ok bool) {
+	var (
+		udh      pdu.UDH
+		optional map[pdu.Tag]pdu.Field
+	)
+
+	switch pd := p.(type) {
+	case *pdu.DeliverSM:
+		if pd.IsDeliveryReceipt() {
+			return
+		}
+
+		seg.SourceAddr = pd.SourceAddr.Address()
+		seg.DestAddr = pd.DestAddr.Address()
+		if enc := pd.Message.Encoding(); enc != nil {
+			seg.DataCoding = enc.DataCoding()
+		} else {
+			seg.UnknownCoding = true
+		}
+		seg.Data, _ = pd.Message.GetMessageData()
+		udh = pd.Message.UDH()
+		optional = pd.OptionalParameters
+
+		// message_payload is used instead of short_message
+		if payload, has := optional[pdu.TagMessagePayload]; has && len(seg.Data) == 0 {
+			if seg.Data, udh, ok = splitUDH(payload.Data, pd.EsmClass); !ok {
+				return
+			}
+		}
+
+	case *pdu.DataSM:
+		if pd.IsDeliveryReceipt() {
+			return
+		}
+
+		seg.SourceAddr = pd.SourceAddr.Address()
+		seg.DestAddr = pd.DestAddr.Address()
+		seg.DataCoding = pd.DataCoding
+		seg.UnknownCoding = coding.FromDataCoding(pd.DataCoding) == nil
+		optional = pd.OptionalParameters
+
+		if payload, has := optional[pdu.TagMessagePayload]; has {
+			if seg.Data, udh, ok = splitUDH(payload.Data, pd.EsmClass); !ok {
+				return
+			}
+		}
+
+	default:
+		return
+	}
+
+	ok = true
+	seg.Received = time.Now()
+
+	if total, seq, ref, found := udh.GetConcatInfo(); found {
+		seg.Total, seg.Seq, seg.Ref, concatenated = total, seq, uint16(ref), true
+		seg.RefKind = SegmentRef8Bit
+		return
+	}
+
+	if total, seq, ref, found := udh.GetConcatInfo16(); found {
+		seg.Total, seg.Seq, seg.Ref, concatenated = total, seq, ref, true
+		seg.RefKind = SegmentRef16Bit
+		return
+	}
+
+	ref, hasRef := optional[pdu.TagSarMsgRefNum]
+	total, hasTotal := optional[pdu.TagSarTotalSegments]
+	seq, hasSeq := optional[pdu.TagSarSegmentSeqnum]
+	if hasRef && hasTotal && hasSeq && len(ref.Data) == 2 && len(total.Data) == 1 && len(seq.Data) == 1 {
+		seg.Ref = uint16(ref.Data[0])<<8 | uint16(ref.Data[1])
+		seg.Total, seg.Seq, concatenated = total.Data[0], seq.Data[0], true
+		seg.RefKind = SegmentRefSAR
+	}
+
+	return
+}
+
+func splitUDH(payload []byte, esmClass byte) (ud []byte, udh pdu.UDH, ok bool) {
+	if esmClass&data.SM_UDH_GSM == 0 || len(payload) == 0 {
+		return payload, nil, true
+	}
+
+	n, err := udh.UnmarshalBinary(payload)
+	if err != nil {
+		return
+	}
+
+	return payload[n:], udh, true
+}
+
+// MemorySegmentStore is in-memory SegmentStore.
+type MemorySegmentStore struct {
+	mu     sync.Mutex
+	groups map[string][]Segment
+}
+
+// NewMemorySegmentStore creates in-memory SegmentStore.
+func NewMemorySegmentStore() *MemorySegmentStore {
+	return &MemorySegmentStore{
+		groups: make(map[string][]Segment),
+	}
+}
+
+// Put implements SegmentStore.
+func (m *MemorySegmentStore) Put(key string, seg Segment) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	segments := m.groups[key]
+	if len(segments) > 0 && segments[0].Total != seg.Total {
+		return len(segments), ErrSegmentConflict
+	}
+
+	for i := range segments {
+		if segments[i].Seq == seg.Seq {
+			segments[i] = seg
+			return len(segments), nil
+		}
+	}
+
+	m.groups[key] = append(segments, seg)
+	return len(segments) + 1, nil
+}
+
+// Take implements SegmentStore.
+func (m *MemorySegmentStore) Take(key string) ([]Segment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	segments := m.groups[key]
+	delete(m.groups, key)
+	return segments, nil
+}
+
+// Expire implements SegmentStore.
+func (m *MemorySegmentStore) Expire(deadline time.Time) (map[string][]Segment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expired := make(map[string][]Segment)
+	for key, segments := range m.groups {
+		if len(segments) > 0 && segments[0].Received.Before(deadline) {
+			expired[key] = segments
+			delete(m.groups, key)
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,207 @@
+package smpp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+
+	"github.com/stretchr/testify/require"
+)
+
+// wire marshals and parses p back, like it was received from SMSC.
+func wire(t *testing.T, p pdu.PDU) pdu.PDU {
+	buf := pdu.NewBuffer(nil)
+	p.Marshal(buf)
+
+	parsed, err := pdu.Parse(buf)
+	require.NoError(t, err)
+	return parsed
+}
+
+func newDeliverSegment(t *testing.T, udh pdu.UDH, text string) pdu.PDU {
+	p := pdu.NewDeliverSM().(*pdu.DeliverSM)
+	_ = p.SourceAddr.SetAddress("1234")
+	_ = p.DestAddr.SetAddress("5678")
+
+	ud, err := coding.UCS2.Encode(text)
+	require.NoError(t, err)
+	require.NoError(t, p.Message.SetMessageDataWithEncoding(ud, coding.UCS2))
+
+	if udh != nil {
+		p.Message.SetUDH(udh)
+		p.EsmClass |= data.SM_UDH_GSM
+	}
+	return wire(t, p)
+}
+
+func newReassembler(ttl time.Duration) (*Reassembler, chan InboundMessage, chan []Segment) {
+	messages, expired := make(chan InboundMessage, 10), make(chan []Segment, 10)
+	r := NewReassembler(ReassemblerSettings{
+		TTL: ttl,
+		OnMessage: func(m InboundMessage) {
+			messages <- m
+		},
+		OnExpired: func(_ string, segments []Segment) {
+			expired <- segments
+		},
+	})
+	return r, messages, expired
+}
+
+func TestReassembler(t *testing.T) {
+	t.Run("8-bit UDH, out of order", func(t *testing.T) {
+		r, messages, _ := newReassembler(time.Minute)
+		defer r.Close()
+
+		var forwarded int
+		onPDU := r.OnPDU(func(pdu.PDU, bool) { forwarded++ })
+
+		onPDU(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(3, 2, 7)}, "ở "), false)
+		onPDU(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(3, 3, 7)}, "đây"), false)
+		onPDU(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(3, 2, 7)}, "ở "), false) // duplicate
+		require.Empty(t, messages)
+
+		onPDU(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(3, 1, 7)}, "Xin chào "), false)
+		require.Equal(t, 4, forwarded)
+
+		m := <-messages
+		require.Equal(t, "Xin chào ở đây", m.Text)
+		require.Equal(t, 3, m.Parts)
+		require.EqualValues(t, 7, m.Ref)
+		require.Equal(t, "1234", m.SourceAddr)
+		require.Equal(t, "5678", m.DestAddr)
+		require.Equal(t, coding.UCS2.DataCoding(), m.DataCoding)
+	})
+
+	t.Run("16-bit UDH", func(t *testing.T) {
+		r, messages, _ := newReassembler(time.Minute)
+		defer r.Close()
+
+		ie := func(seq byte) pdu.UDH {
+			return pdu.UDH{{ID: data.UDH_CONCAT_MSG_16_BIT_REF, Data: []byte{0x01, 0x02, 2, seq}}}
+		}
+		require.NoError(t, r.Handle(newDeliverSegment(t, ie(2), "world")))
+		require.NoError(t, r.Handle(newDeliverSegment(t, ie(1), "hello ")))
+
+		m := <-messages
+		require.Equal(t, "hello world", m.Text)
+		require.EqualValues(t, 0x0102, m.Ref)
+	})
+
+	t.Run("SAR TLVs", func(t *testing.T) {
+		r, messages, _ := newReassembler(time.Minute)
+		defer r.Close()
+
+		for seq, text := range []string{"part 1 ", "part 2"} {
+			p := pdu.NewDataSM().(*pdu.DataSM)
+			_ = p.SourceAddr.SetAddress("1234")
+			_ = p.DestAddr.SetAddress("5678")
+			p.RegisterOptionalParam(pdu.Field{Tag: pdu.TagMessagePayload, Data: []byte(text)})
+			p.RegisterOptionalParam(pdu.Field{Tag: pdu.TagSarMsgRefNum, Data: []byte{0x00, 0x09}})
+			p.RegisterOptionalParam(pdu.Field{Tag: pdu.TagSarTotalSegments, Data: []byte{2}})
+			p.RegisterOptionalParam(pdu.Field{Tag: pdu.TagSarSegmentSeqnum, Data: []byte{byte(seq + 1)}})
+			require.NoError(t, r.Handle(wire(t, p)))
+		}
+
+		m := <-messages
+		require.Equal(t, "part 1 part 2", m.Text)
+		require.EqualValues(t, 9, m.Ref)
+	})
+
+	t.Run("single part and receipts", func(t *testing.T) {
+		r, messages, _ := newReassembler(time.Minute)
+		defer r.Close()
+
+		require.NoError(t, r.Handle(newDeliverSegment(t, nil, "hi")))
+		require.Equal(t, "hi", (<-messages).Text)
+
+		receipt := pdu.NewDeliverSM().(*pdu.DeliverSM)
+		receipt.EsmClass = data.SM_SMSC_DLV_RCPT_TYPE
+		require.NoError(t, r.Handle(receipt))
+		require.NoError(t, r.Handle(pdu.NewEnquireLink()))
+		require.Empty(t, messages)
+	})
+
+	t.Run("different senders are not mixed", func(t *testing.T) {
+		r, messages, _ := newReassembler(time.Minute)
+		defer r.Close()
+
+		other := newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(2, 2, 1)}, "b")
+		_ = other.(*pdu.DeliverSM).SourceAddr.SetAddress("4321")
+
+		require.NoError(t, r.Handle(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(2, 1, 1)}, "a")))
+		require.NoError(t, r.Handle(other))
+		require.Empty(t, messages)
+	})
+
+	t.Run("8-bit and 16-bit references are not mixed", func(t *testing.T) {
+		r, messages, _ := newReassembler(time.Minute)
+		defer r.Close()
+
+		require.NoError(t, r.Handle(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(2, 1, 0x12)}, "a")))
+		require.NoError(t, r.Handle(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage16(2, 2, 0x0012)}, "b")))
+		require.Empty(t, messages)
+
+		require.NoError(t, r.Handle(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(2, 2, 0x12)}, "c")))
+		m := <-messages
+		require.Equal(t, "ac", m.Text)
+		require.EqualValues(t, 0x12, m.Ref)
+	})
+
+	t.Run("malformed segments", func(t *testing.T) {
+		r, messages, _ := newReassembler(time.Minute)
+		defer r.Close()
+
+		segment := func(total, seq byte, text string) pdu.PDU {
+			return newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(total, seq, 5)}, text)
+		}
+
+		require.ErrorIs(t, r.Handle(segment(2, 0, "x")), ErrInvalidSegment)
+		require.ErrorIs(t, r.Handle(segment(2, 3, "x")), ErrInvalidSegment)
+
+		require.NoError(t, r.Handle(segment(2, 1, "a")))
+		require.ErrorIs(t, r.Handle(segment(3, 3, "x")), ErrSegmentConflict)
+		require.Empty(t, messages)
+
+		require.NoError(t, r.Handle(segment(2, 2, "b")))
+		m := <-messages
+		require.Equal(t, "ab", m.Text)
+		require.Equal(t, 2, m.Parts)
+	})
+
+	t.Run("unknown data coding", func(t *testing.T) {
+		r, messages, _ := newReassembler(time.Minute)
+		defer r.Close()
+
+		p := pdu.NewDataSM().(*pdu.DataSM)
+		p.DataCoding = 0x05
+		p.RegisterOptionalParam(pdu.Field{Tag: pdu.TagMessagePayload, Data: []byte("@@")})
+		require.NoError(t, r.Handle(wire(t, p)))
+
+		m := <-messages
+		require.True(t, m.UnknownCoding)
+		require.Empty(t, m.Text)
+		require.Equal(t, []byte("@@"), m.Data)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		r, messages, expired := newReassembler(20 * time.Millisecond)
+		defer r.Close()
+
+		require.NoError(t, r.Handle(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(2, 1, 3)}, "a")))
+
+		select {
+		case segments := <-expired:
+			require.Len(t, segments, 1)
+			require.EqualValues(t, 3, segments[0].Ref)
+		case <-time.After(time.Second):
+			t.Fatal("incomplete message did not expire")
+		}
+
+		require.NoError(t, r.Handle(newDeliverSegment(t, pdu.UDH{pdu.NewIEConcatMessage(2, 2, 3)}, "b")))
+		require.Empty(t, messages)
+	})
+}
@@ -0,0 +1,120 @@
+package smpp
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+)
+
+// PermanentBindStatuses are bind_resp statuses which are unlikely to change by retrying,
+// typically used as BackoffRebindPolicy.StopOnStatuses.
+var PermanentBindStatuses = []data.CommandStatusType{
+	data.ESME_RINVPASWD,
+	data.ESME_RINVSYSID,
+	data.ESME_RALYBND,
+}
+
+// RebindPolicy decides whether and when Session rebinds after its connection is lost.
+type RebindPolicy interface {
+	// Next is called before each rebinding attempt.
+	//
+	// `attempt` is the number of failed attempts so far, `elapsed` is duration since connection was lost
+	// and `err` is the error of the last failed attempt (nil before the first attempt).
+	//
+	// Next returns delay to wait before attempting, or false to give up rebinding.
+	Next(attempt int, elapsed time.Duration, err error) (delay time.Duration, ok bool)
+}
+
+// FixedRebindPolicy rebinds immediately, then waits fixed interval between failed attempts, forever.
+type FixedRebindPolicy time.Duration
+
+// Next implements RebindPolicy.
+func (p FixedRebindPolicy) Next(attempt int, _ time.Duration, _ error) (time.Duration, bool) {
+	if attempt == 0 {
+		return 0, true
+	}
+	return time.Duration(p), true
+}
+
+// DefaultBackoffInitialInterval is BackoffRebindPolicy.InitialInterval if it is zero.
+const DefaultBackoffInitialInterval = time.Second
+
+// BackoffRebindPolicy waits exponentially growing, optionally randomized, delay between attempts.
+type BackoffRebindPolicy struct {
+	// InitialInterval is delay before the first attempt. Zero defaults to DefaultBackoffInitialInterval.
+	InitialInterval time.Duration
+
+	// MaxInterval caps delay between attempts, jitter included. Zero means no cap.
+	MaxInterval time.Duration
+
+	// Multiplier grows delay after each failed attempt. Values below 1 default to 2.
+	Multiplier float64
+
+	// Jitter randomizes each delay within [delay*(1-Jitter), delay*(1+Jitter)].
+	// Must be within [0, 1], zero disables randomization.
+	Jitter float64
+
+	// MaxAttempts gives up after the number of failed attempts. Zero means no limit.
+	MaxAttempts int
+
+	// MaxElapsed gives up once next attempt would start after the duration since connection was lost.
+	// Zero means no limit.
+	MaxElapsed time.Duration
+
+	// StopOnStatuses gives up when SMSC rejects bind with one of the statuses, see PermanentBindStatuses.
+	StopOnStatuses []data.CommandStatusType
+}
+
+// Next implements RebindPolicy.
+func (p *BackoffRebindPolicy) Next(attempt int, elapsed time.Duration, err error) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+		return 0, false
+	}
+
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		for _, status := range p.StopOnStatuses {
+			if bindErr.Status == status {
+				return 0, false
+			}
+		}
+	}
+
+	delay := p.delay(attempt)
+	if p.MaxElapsed > 0 && elapsed+delay > p.MaxElapsed {
+		return 0, false
+	}
+	return delay, true
+}
+
+func (p *BackoffRebindPolicy) delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	initial := p.InitialInterval
+	if initial <= 0 {
+		initial = DefaultBackoffInitialInterval
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt))
+	if p.MaxInterval > 0 && delay > float64(p.MaxInterval) {
+		delay = float64(p.MaxInterval)
+	}
+
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1) //nolint:gosec
+		if p.MaxInterval > 0 && delay > float64(p.MaxInterval) {
+			delay = float64(p.MaxInterval)
+		}
+	}
+
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
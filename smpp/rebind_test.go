@@ -0,0 +1,229 @@
+package smpp
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixedRebindPolicy(t *testing.T) {
+	p := FixedRebindPolicy(time.Second)
+
+	delay, ok := p.Next(0, 0, nil)
+	require.True(t, ok)
+	require.Zero(t, delay)
+
+	delay, ok = p.Next(100, time.Hour, fmt.Errorf("error"))
+	require.True(t, ok)
+	require.Equal(t, time.Second, delay)
+}
+
+func TestBackoffRebindPolicy(t *testing.T) {
+	t.Run("exponential", func(t *testing.T) {
+		p := &BackoffRebindPolicy{
+			InitialInterval: 100 * time.Millisecond,
+			MaxInterval:     time.Second,
+		}
+
+		for attempt, expected := range []time.Duration{
+			100 * time.Millisecond,
+			200 * time.Millisecond,
+			400 * time.Millisecond,
+			800 * time.Millisecond,
+			time.Second,
+			time.Second,
+		} {
+			delay, ok := p.Next(attempt, 0, nil)
+			require.True(t, ok)
+			require.Equal(t, expected, delay)
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		p := &BackoffRebindPolicy{
+			InitialInterval: time.Second,
+			Multiplier:      3,
+			Jitter:          0.5,
+		}
+
+		for i := 0; i < 100; i++ {
+			delay, ok := p.Next(1, 0, nil)
+			require.True(t, ok)
+			require.GreaterOrEqual(t, delay, 1500*time.Millisecond)
+			require.LessOrEqual(t, delay, 4500*time.Millisecond)
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		p := &BackoffRebindPolicy{MaxInterval: 3 * time.Second, Jitter: 0.5}
+
+		for i := 0; i < 100; i++ {
+			delay, ok := p.Next(0, 0, nil)
+			require.True(t, ok)
+			require.GreaterOrEqual(t, delay, DefaultBackoffInitialInterval/2)
+			require.LessOrEqual(t, delay, 3*DefaultBackoffInitialInterval/2)
+
+			delay, ok = p.Next(5, 0, nil)
+			require.True(t, ok)
+			require.GreaterOrEqual(t, delay, 1500*time.Millisecond)
+			require.LessOrEqual(t, delay, 3*time.Second)
+		}
+	})
+
+	t.Run("give up", func(t *testing.T) {
+		p := &BackoffRebindPolicy{
+			InitialInterval: time.Second,
+			MaxAttempts:     3,
+			MaxElapsed:      10 * time.Second,
+			StopOnStatuses:  PermanentBindStatuses,
+		}
+
+		_, ok := p.Next(2, 0, nil)
+		require.True(t, ok)
+
+		_, ok = p.Next(3, 0, nil)
+		require.False(t, ok)
+
+		// next attempt would start after 2s + 9s
+		_, ok = p.Next(1, 9*time.Second, nil)
+		require.False(t, ok)
+
+		_, ok = p.Next(1, 0, fmt.Errorf("rebind: %w", &BindError{Status: data.ESME_RBINDFAIL}))
+		require.True(t, ok)
+
+		_, ok = p.Next(1, 0, fmt.Errorf("rebind: %w", &BindError{Status: data.ESME_RINVPASWD}))
+		require.False(t, ok)
+	})
+}
+
+func TestSessionRebindPolicy(t *testing.T) {
+	newSMSC := func(t *testing.T, status *atomic.Value) *smsctest.Server {
+		status.Store(data.ESME_ROK)
+
+		smsc := smsctest.NewServer(smsctest.Config{
+			Authenticate: func(*pdu.BindRequest) data.CommandStatusType {
+				return status.Load().(data.CommandStatusType)
+			},
+		})
+		t.Cleanup(smsc.Close)
+		return smsc
+	}
+
+	t.Run("rebound", func(t *testing.T) {
+		var status atomic.Value
+		smsc := newSMSC(t, &status)
+
+		var attempts int32
+		rebound := make(chan int, 1)
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout: time.Second,
+			RebindPolicy: &BackoffRebindPolicy{
+				InitialInterval: 10 * time.Millisecond,
+				StopOnStatuses:  PermanentBindStatuses,
+			},
+			OnRebindAttempt: func(attempt int) {
+				if atomic.AddInt32(&attempts, 1) == 2 {
+					status.Store(data.ESME_ROK)
+				}
+			},
+			OnRebound: func(attempts int, _ time.Duration) {
+				rebound <- attempts
+			},
+		}, 0)
+		require.NoError(t, err)
+		defer func() {
+			_ = session.Close()
+		}()
+
+		// first rebinding attempt is rejected with temporary status
+		status.Store(data.ESME_RBINDFAIL)
+		smsc.CloseConnections()
+
+		select {
+		case n := <-rebound:
+			require.Equal(t, 2, n)
+		case <-time.After(time.Second):
+			t.Fatal("session is not rebound")
+		}
+		require.Len(t, smsc.ReceivedOf(data.BIND_TRANSCEIVER), 3)
+		require.Eventually(t, func() bool {
+			return smsc.Bound() == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("stop on status", func(t *testing.T) {
+		var status atomic.Value
+		smsc := newSMSC(t, &status)
+
+		giveUp := make(chan error, 1)
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout: time.Second,
+			RebindPolicy: &BackoffRebindPolicy{
+				InitialInterval: 10 * time.Millisecond,
+				StopOnStatuses:  PermanentBindStatuses,
+			},
+			OnRebindGiveUp: func(attempts int, err error) {
+				require.Equal(t, 1, attempts)
+				giveUp <- err
+			},
+		}, 0)
+		require.NoError(t, err)
+
+		status.Store(data.ESME_RINVPASWD)
+		smsc.CloseConnections()
+
+		select {
+		case err := <-giveUp:
+			var bindErr *BindError
+			require.ErrorAs(t, err, &bindErr)
+			require.Equal(t, data.ESME_RINVPASWD, bindErr.Status)
+		case <-time.After(time.Second):
+			t.Fatal("rebinding is not given up")
+		}
+		require.Equal(t, Closed, atomic.LoadInt32(&session.state))
+		require.Len(t, smsc.ReceivedOf(data.BIND_TRANSCEIVER), 2)
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		var status atomic.Value
+		smsc := newSMSC(t, &status)
+
+		var attempts int32
+		giveUp := make(chan int, 1)
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout: time.Second,
+			RebindPolicy: &BackoffRebindPolicy{
+				InitialInterval: time.Millisecond,
+				MaxAttempts:     3,
+			},
+			OnRebindAttempt: func(int) {
+				atomic.AddInt32(&attempts, 1)
+			},
+			OnRebindGiveUp: func(attempts int, _ error) {
+				giveUp <- attempts
+			},
+		}, 0)
+		require.NoError(t, err)
+		defer func() {
+			_ = session.Close()
+		}()
+
+		status.Store(data.ESME_RBINDFAIL)
+		smsc.CloseConnections()
+
+		select {
+		case n := <-giveUp:
+			require.Equal(t, 3, n)
+		case <-time.After(time.Second):
+			t.Fatal("rebinding is not given up")
+		}
+		require.EqualValues(t, 3, atomic.LoadInt32(&attempts))
+	})
+}
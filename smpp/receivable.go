@@ -2,10 +2,12 @@ package smpp
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/sujit-baniya/protocol/smpp/data"
 	"github.com/sujit-baniya/protocol/smpp/pdu"
 )
 
@@ -15,6 +17,7 @@ type receivable struct {
 	wg         sync.WaitGroup
 	settings   Settings
 	conn       *Connection
+	metrics    Metrics
 	aliveState int32
 }
 
@@ -22,6 +25,7 @@ func newReceivable(conn *Connection, settings Settings) *receivable {
 	r := &receivable{
 		settings: settings,
 		conn:     conn,
+		metrics:  metricsOf(&settings),
 	}
 	r.ctx, r.cancel = context.WithCancel(context.Background())
 
@@ -39,8 +43,9 @@ func (t *receivable) close(state State) (err error) {
 		// wait daemons
 		t.wg.Wait()
 
-		// close connection to notify daemons to stop
-		if state != StoppingProcessOnly {
+		// close connection to notify daemons to stop.
+		// On unbind, connection is left to OnClosed, which closes it once unbind_resp is written out.
+		if state != StoppingProcessOnly && (state != UnbindClosing || t.settings.OnClosed == nil) {
 			err = t.conn.Close()
 		}
 
@@ -95,6 +100,15 @@ func (t *receivable) loop() {
 			p, err = pdu.Parse(t.conn)
 		}
 
+		if err == nil {
+			t.metrics.PDUReceived(t.settings.sessionID, p.GetHeader().CommandID)
+		}
+
+		// unknown command does not damage following PDU(s)
+		if t.nackUnknown(err) {
+			continue
+		}
+
 		// check error
 		if closeOnError := t.check(err); closeOnError || t.handleOrClose(p) {
 			if closeOnError {
@@ -114,11 +128,9 @@ func (t *receivable) handleOrClose(p pdu.PDU) (closing bool) {
 			}
 
 		case *pdu.Unbind:
+			// response is queued before closing, transmitter writes it out before stopping
 			if t.settings.response != nil {
 				t.settings.response(pp.GetResponse())
-
-				// wait to send response before closing
-				time.Sleep(50 * time.Millisecond)
 			}
 
 			closing = true
@@ -138,3 +150,29 @@ func (t *receivable) handleOrClose(p pdu.PDU) (closing bool) {
 	}
 	return
 }
+
+// nackUnknown responds generic_nack to request with unknown command_id.
+func (t *receivable) nackUnknown(err error) bool {
+	var unknown *pdu.UnknownCommandError
+	if !errors.As(err, &unknown) {
+		return false
+	}
+
+	if t.settings.OnReceivingError != nil {
+		t.settings.OnReceivingError(err)
+	}
+
+	if unknown.Header.CommandID&data.GENERIC_NACK == 0 {
+		t.nack(unknown.Header.SequenceNumber, data.ESME_RINVCMDID)
+	}
+	return true
+}
+
+func (t *receivable) nack(sequence int32, status data.CommandStatusType) {
+	if t.settings.response != nil {
+		nack := pdu.NewGenericNack().(*pdu.GenericNack)
+		nack.CommandStatus = status
+		nack.SetSequenceNumber(sequence)
+		t.settings.response(nack)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -38,7 +39,7 @@ func TestReceive(t *testing.T) {
 		_ = receiver.Close()
 	}()
 
-	require.Equal(t, "MelroseLabsSMSC", receiver.Receiver().SystemID())
+	require.Equal(t, smsctest.DefaultSystemID, receiver.Receiver().SystemID())
 
 	time.Sleep(time.Second)
 	receiver.rebind()
@@ -0,0 +1,124 @@
+package smpp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTimedCapture(offsets ...time.Duration) *Capture {
+	start := time.Now()
+	c := &Capture{}
+	for i, offset := range offsets {
+		c.Records = append(c.Records, CaptureRecord{
+			Time:      start.Add(offset),
+			Conn:      uint64(i%2 + 1),
+			Direction: CaptureWritten,
+		})
+	}
+	return c
+}
+
+func TestCaptureReplay(t *testing.T) {
+	c := newTimedCapture(0, 20*time.Millisecond, 60*time.Millisecond)
+	c.Records = append(c.Records, CaptureRecord{Time: c.Records[0].Time, Direction: CaptureRead})
+
+	replay := func(opts ReplayOptions) (replayed []int, elapsed time.Duration) {
+		start := time.Now()
+		require.NoError(t, c.Replay(context.Background(), opts, func(i int) error {
+			replayed = append(replayed, i)
+			return nil
+		}))
+		return replayed, time.Since(start)
+	}
+
+	replayed, elapsed := replay(ReplayOptions{})
+	require.Equal(t, []int{0, 1, 2}, replayed)
+	require.GreaterOrEqual(t, elapsed, 60*time.Millisecond)
+
+	_, elapsed = replay(ReplayOptions{Speed: 4})
+	require.GreaterOrEqual(t, elapsed, 15*time.Millisecond)
+	require.Less(t, elapsed, 60*time.Millisecond)
+
+	replayed, _ = replay(ReplayOptions{Conn: 1, Speed: -1})
+	require.Equal(t, []int{0, 2}, replayed)
+
+	replayed, _ = replay(ReplayOptions{Direction: CaptureRead})
+	require.Equal(t, []int{3}, replayed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, c.Replay(ctx, ReplayOptions{}, func(int) error { return nil }), context.DeadlineExceeded)
+}
+
+func TestReplayConn(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	var dataBuf, index bytes.Buffer
+	recordSession(t, smsc, NewRecorder(&dataBuf, &index))
+	capture, err := ReadCapture(dataBuf.Bytes(), &index)
+	require.NoError(t, err)
+
+	replayed := smsctest.NewServer(smsctest.Config{})
+	defer replayed.Close()
+
+	conn, err := net.Dial("tcp", replayed.Addr())
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	require.NoError(t, capture.ReplayConn(context.Background(), conn, ReplayOptions{Speed: -1}))
+
+	var responses []data.CommandIDType
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	for len(responses) < 3 {
+		p, err := pdu.Parse(conn)
+		require.NoError(t, err)
+		responses = append(responses, p.GetHeader().CommandID)
+	}
+	require.Equal(t, []data.CommandIDType{data.BIND_TRANSCEIVER_RESP, data.SUBMIT_SM_RESP, data.UNBIND_RESP}, responses)
+
+	submitted := replayed.ReceivedOf(data.SUBMIT_SM)
+	require.Len(t, submitted, 1)
+	require.Equal(t, capture.Records[findRecord(capture, data.SUBMIT_SM)].SequenceNumber, submitted[0].GetSequenceNumber())
+}
+
+func TestReplaySession(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	var dataBuf, index bytes.Buffer
+	recordSession(t, smsc, NewRecorder(&dataBuf, &index))
+	capture, err := ReadCapture(dataBuf.Bytes(), &index)
+	require.NoError(t, err)
+
+	replayed := smsctest.NewServer(smsctest.Config{})
+	defer replayed.Close()
+
+	session, err := NewSession(TRXConnector(replayed.Dial, Auth{SMSC: replayed.Addr()}), Settings{
+		ReadTimeout: time.Second,
+	}, 0)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+
+	require.NoError(t, capture.ReplaySession(context.Background(), session, ReplayOptions{}))
+	require.Eventually(t, func() bool {
+		return len(replayed.ReceivedOf(data.SUBMIT_SM)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	require.Len(t, replayed.ReceivedOf(data.BIND_TRANSCEIVER), 1)
+	require.Empty(t, replayed.ReceivedOf(data.UNBIND))
+	require.Equal(t, "esme", replayed.ReceivedOf(data.SUBMIT_SM)[0].(*pdu.SubmitSM).SourceAddr.Address())
+}
@@ -0,0 +1,64 @@
+package smpp
+
+import (
+	"sync/atomic"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+const (
+	// MinSequenceNumber is the lowest sequence_number allowed for requests.
+	MinSequenceNumber int32 = 0x00000001
+
+	// MaxSequenceNumber is the highest sequence_number allowed for requests.
+	MaxSequenceNumber int32 = 0x7FFFFFFF
+)
+
+// SequenceGenerator generates sequence_number of outgoing requests.
+//
+// Implementations must be safe for concurrent use and return values
+// within MinSequenceNumber..MaxSequenceNumber.
+type SequenceGenerator interface {
+	Next() int32
+}
+
+// SequenceCounter is SequenceGenerator which counts up and wraps back to
+// MinSequenceNumber after MaxSequenceNumber.
+//
+// To continue sequence across restarts, persist Last and pass it to
+// NewSequenceCounter on startup.
+type SequenceCounter struct {
+	last int32
+}
+
+// NewSequenceCounter returns SequenceCounter continuing after last.
+func NewSequenceCounter(last int32) *SequenceCounter {
+	return &SequenceCounter{last: last}
+}
+
+// Next implements SequenceGenerator.
+func (c *SequenceCounter) Next() int32 {
+	for {
+		last := atomic.LoadInt32(&c.last)
+
+		next := last + 1
+		if last < MinSequenceNumber || last >= MaxSequenceNumber {
+			next = MinSequenceNumber
+		}
+
+		if atomic.CompareAndSwapInt32(&c.last, last, next) {
+			return next
+		}
+	}
+}
+
+// Last returns the last generated sequence number.
+func (c *SequenceCounter) Last() int32 {
+	return atomic.LoadInt32(&c.last)
+}
+
+// isRequest returns true if command_id of PDU is not a response.
+func isRequest(p pdu.PDU) bool {
+	return p.GetHeader().CommandID&data.GENERIC_NACK == 0
+}
@@ -0,0 +1,107 @@
+package smpp
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceCounter(t *testing.T) {
+	t.Run("wraps", func(t *testing.T) {
+		c := NewSequenceCounter(MaxSequenceNumber - 1)
+		require.Equal(t, MaxSequenceNumber, c.Next())
+		require.Equal(t, MinSequenceNumber, c.Next())
+		require.Equal(t, int32(2), c.Next())
+		require.Equal(t, int32(2), c.Last())
+
+		require.Equal(t, MinSequenceNumber, NewSequenceCounter(-5).Next())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		c := NewSequenceCounter(0)
+
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			seen = make(map[int32]struct{})
+		)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					v := c.Next()
+					mu.Lock()
+					seen[v] = struct{}{}
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Len(t, seen, 8000)
+		require.Equal(t, int32(8000), c.Last())
+	})
+}
+
+func TestSessionSequenceGenerator(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	sequence := NewSequenceCounter(100)
+	session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+		ReadTimeout:       time.Second,
+		SequenceGenerator: sequence,
+		OnPDU:             func(pdu.PDU, bool) {},
+	}, 100*time.Millisecond)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+	require.Equal(t, sequence, session.SequenceGenerator())
+
+	submit := func() pdu.PDU {
+		p := pdu.NewSubmitSM()
+		require.NoError(t, session.Transceiver().Submit(p))
+		return p
+	}
+
+	require.Equal(t, int32(101), submit().GetSequenceNumber())
+	require.Equal(t, int32(102), submit().GetSequenceNumber())
+
+	require.Eventually(t, func() bool {
+		return len(smsc.ReceivedOf(data.SUBMIT_SM)) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// sequence continues across rebind
+	trx := session.Transceiver()
+	require.NoError(t, smsc.Unbind())
+	require.Eventually(t, func() bool {
+		return session.Transceiver() != trx
+	}, time.Second, 10*time.Millisecond)
+
+	last := submit().GetSequenceNumber()
+	require.Greater(t, last, int32(102))
+
+	require.Eventually(t, func() bool {
+		return len(smsc.ReceivedOf(data.SUBMIT_SM)) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	var sequences []int32
+	for _, p := range smsc.ReceivedOf(data.SUBMIT_SM) {
+		sequences = append(sequences, p.GetSequenceNumber())
+	}
+	require.Equal(t, []int32{101, 102, last}, sequences)
+
+	// responses keep sequence number of their requests
+	resp := pdu.NewDeliverSM().GetResponse()
+	resp.SetSequenceNumber(7)
+	require.NoError(t, session.Transceiver().Submit(resp))
+	require.Equal(t, int32(7), resp.GetSequenceNumber())
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+var (
+	// ErrServerClosed is returned by Serve after Close is called.
+	ErrServerClosed = fmt.Errorf("smpp server closed")
+)
+
+// Authenticator validates credentials carried by bind requests.
+type Authenticator interface {
+	// Authenticate returns ESME_ROK to accept the bind, or an error status
+	// (ESME_RINVPASWD, ESME_RINVSYSID, ESME_RBINDFAIL, ...) which is sent
+	// back in bind_resp.
+	Authenticate(req *pdu.BindRequest) data.CommandStatusType
+}
+
+// AuthenticatorFunc adapts an ordinary function to Authenticator.
+type AuthenticatorFunc func(req *pdu.BindRequest) data.CommandStatusType
+
+// Authenticate implements Authenticator.
+func (f AuthenticatorFunc) Authenticate(req *pdu.BindRequest) data.CommandStatusType {
+	return f(req)
+}
+
+// Settings for SMSC server.
+type Settings struct {
+	// SystemID is returned to ESME within bind_resp.
+	SystemID string
+
+	// Authenticator validates bind requests. Nil accepts all binds.
+	Authenticator Authenticator
+
+	// InterfaceVersion is the highest interface version supported, returned as
+	// sc_interface_version within bind_resp. Zero means data.SMPP_V50.
+	InterfaceVersion byte
+
+	// BindTimeout is the maximum duration waiting for the first bind
+	// request after connection accepted. Zero means no limit.
+	BindTimeout time.Duration
+
+	// ReadTimeout closes a bound session which has been silent for this duration.
+	// Zero disables idle detection.
+	ReadTimeout time.Duration
+
+	// WriteTimeout is timeout for writing PDU to ESME.
+	WriteTimeout time.Duration
+
+	// OnBound notifies a newly bound session.
+	OnBound func(*Session)
+
+	// OnPDU handles PDU received from bound ESME.
+	//
+	// EnquireLink and Unbind are handled automatically. When OnPDU is nil,
+	// requests are responded automatically with ESME_ROK.
+	OnPDU func(s *Session, p pdu.PDU)
+
+	// OnReceivingError notifies error happened while reading PDU from ESME.
+	OnReceivingError func(s *Session, err error)
+
+	// OnClosed notifies session closed.
+	OnClosed func(*Session)
+}
+
+// Server accepts ESME connections and handles their bind requests.
+type Server struct {
+	settings Settings
+
+	mu       sync.Mutex
+	listener net.Listener
+	sessions map[string]*Session
+	accepted map[*Session]struct{} // all served connections, bound or not
+
+	wg         sync.WaitGroup
+	aliveState int32
+}
+
+// New creates SMSC server.
+func New(settings Settings) *Server {
+	return &Server{
+		settings: settings,
+		sessions: make(map[string]*Session),
+		accepted: make(map[*Session]struct{}),
+	}
+}
+
+// ListenAndServe listens on TCP address and serves incoming connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on listener until Close is called.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if atomic.LoadInt32(&s.aliveState) != 0 {
+		s.mu.Unlock()
+		_ = l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if atomic.LoadInt32(&s.aliveState) != 0 {
+				return ErrServerClosed
+			}
+			if nErr, ok := err.(net.Error); ok && nErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		s.ServeConn(conn)
+	}
+}
+
+// Addr returns listening address, nil if server is not serving.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// ServeConn serves an already established connection, i.e. net.Pipe.
+func (s *Server) ServeConn(conn net.Conn) {
+	sess := newSession(s, conn)
+
+	// Close either sees the session or is seen by the check
+	s.mu.Lock()
+	if atomic.LoadInt32(&s.aliveState) != 0 {
+		s.mu.Unlock()
+		_ = conn.Close()
+		return
+	}
+	s.accepted[sess] = struct{}{}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			s.mu.Lock()
+			delete(s.accepted, sess)
+			s.mu.Unlock()
+
+			s.wg.Done()
+		}()
+		sess.serve()
+	}()
+}
+
+// Sessions returns bound sessions.
+func (s *Server) Sessions() (sessions []*Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions = make([]*Session, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	return
+}
+
+// Close stops listening, unbinds bound sessions and closes all served connections.
+func (s *Server) Close() (err error) {
+	s.mu.Lock()
+	if !atomic.CompareAndSwapInt32(&s.aliveState, 0, 1) {
+		s.mu.Unlock()
+		return
+	}
+
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	sessions := make([]*Session, 0, len(s.accepted))
+	for sess := range s.accepted {
+		sessions = append(sessions, sess)
+	}
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		_ = sess.Close()
+	}
+
+	s.wg.Wait()
+	return
+}
+
+func (s *Server) interfaceVersion() byte {
+	if s.settings.InterfaceVersion == 0 {
+		return data.SMPP_V50
+	}
+	return s.settings.InterfaceVersion
+}
+
+func (s *Server) authenticate(req *pdu.BindRequest) data.CommandStatusType {
+	if s.settings.Authenticator == nil {
+		return data.ESME_ROK
+	}
+	return s.settings.Authenticator.Authenticate(req)
+}
+
+func (s *Server) register(sess *Session) {
+	s.mu.Lock()
+	s.sessions[sess.ID] = sess
+	s.mu.Unlock()
+}
+
+func (s *Server) unregister(sess *Session) {
+	s.mu.Lock()
+	delete(s.sessions, sess.ID)
+	s.mu.Unlock()
+}
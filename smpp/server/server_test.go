@@ -0,0 +1,256 @@
+package server
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, settings Settings) (*Server, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := New(settings)
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	return srv, l.Addr().String()
+}
+
+func TestBind(t *testing.T) {
+	_, addr := newTestServer(t, Settings{
+		SystemID: "TestSMSC",
+		Authenticator: AuthenticatorFunc(func(req *pdu.BindRequest) data.CommandStatusType {
+			if req.SystemID != "esme" {
+				return data.ESME_RINVSYSID
+			}
+			if req.Password != "secret" {
+				return data.ESME_RINVPASWD
+			}
+			return data.ESME_ROK
+		}),
+	})
+
+	t.Run("Accepted", func(t *testing.T) {
+		for _, c := range []smpp.Connector{
+			smpp.TXConnector(smpp.NonTLSDialer, smpp.Auth{SMSC: addr, SystemID: "esme", Password: "secret"}),
+			smpp.RXConnector(smpp.NonTLSDialer, smpp.Auth{SMSC: addr, SystemID: "esme", Password: "secret"}),
+			smpp.TRXConnector(smpp.NonTLSDialer, smpp.Auth{SMSC: addr, SystemID: "esme", Password: "secret"}),
+		} {
+			conn, err := c.Connect()
+			require.NoError(t, err)
+			_ = conn.Close()
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		_, err := smpp.TRXConnector(smpp.NonTLSDialer, smpp.Auth{SMSC: addr, SystemID: "esme", Password: "wrong"}).Connect()
+		require.Error(t, err)
+
+		_, err = smpp.TRXConnector(smpp.NonTLSDialer, smpp.Auth{SMSC: addr, SystemID: "other", Password: "secret"}).Connect()
+		require.Error(t, err)
+	})
+}
+
+func TestBindInterfaceVersion(t *testing.T) {
+	bound := make(chan *Session, 1)
+	_, addr := newTestServer(t, Settings{
+		InterfaceVersion: data.SMPP_V34,
+		OnBound: func(s *Session) {
+			bound <- s
+		},
+	})
+
+	for _, requested := range []byte{data.SMPP_V34, data.SMPP_V50} {
+		conn, err := smpp.TRXConnector(smpp.NonTLSDialer, smpp.Auth{SMSC: addr, SystemID: "esme", InterfaceVersion: requested}).Connect()
+		require.NoError(t, err)
+		require.Equal(t, data.SMPP_V34, conn.InterfaceVersion())
+		require.Equal(t, data.SMPP_V34, (<-bound).InterfaceVersion)
+		_ = conn.Close()
+	}
+}
+
+func TestSubmitAndDeliver(t *testing.T) {
+	bound := make(chan *Session, 1)
+	srv, addr := newTestServer(t, Settings{
+		SystemID: "TestSMSC",
+		OnBound: func(s *Session) {
+			bound <- s
+		},
+		OnPDU: func(s *Session, p pdu.PDU) {
+			if req, ok := p.(*pdu.SubmitSM); ok {
+				resp := req.GetResponse().(*pdu.SubmitSMResp)
+				resp.MessageID = "msg-1"
+				_ = s.Submit(resp)
+			}
+		},
+	})
+
+	var countSubmitSMResp, countDeliverSM int32
+	session, err := smpp.NewSession(
+		smpp.TRXConnector(smpp.NonTLSDialer, smpp.Auth{SMSC: addr, SystemID: "esme"}),
+		smpp.Settings{
+			ReadTimeout: 2 * time.Second,
+			OnPDU: func(p pdu.PDU, _ bool) {
+				switch pd := p.(type) {
+				case *pdu.SubmitSMResp:
+					require.Equal(t, "msg-1", pd.MessageID)
+					atomic.AddInt32(&countSubmitSMResp, 1)
+
+				case *pdu.DeliverSM:
+					atomic.AddInt32(&countDeliverSM, 1)
+				}
+			},
+		}, -1)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+	require.Equal(t, "TestSMSC", session.Transceiver().SystemID())
+
+	var peer *Session
+	select {
+	case peer = <-bound:
+	case <-time.After(time.Second):
+		t.Fatal("session is not bound")
+	}
+	require.Equal(t, "esme", peer.SystemID)
+	require.Equal(t, pdu.Transceiver, peer.BindingType)
+	require.Len(t, srv.Sessions(), 1)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, session.Transceiver().Submit(pdu.NewSubmitSM()))
+	}
+	require.NoError(t, peer.Submit(pdu.NewDeliverSM()))
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&countSubmitSMResp) == 5 && atomic.LoadInt32(&countDeliverSM) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestRequestBeforeBind(t *testing.T) {
+	_, addr := newTestServer(t, Settings{})
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	c := smpp.NewConnection(conn)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	req := pdu.NewSubmitSM()
+	_, err = c.WritePDU(req)
+	require.NoError(t, err)
+
+	require.NoError(t, c.SetReadTimeout(time.Second))
+	p, err := pdu.Parse(c)
+	require.NoError(t, err)
+	require.True(t, p.IsGNack())
+	require.Equal(t, data.ESME_RINVBNDSTS, p.GetHeader().CommandStatus)
+	require.Equal(t, req.GetSequenceNumber(), p.GetSequenceNumber())
+}
+
+func TestUnknownCommand(t *testing.T) {
+	_, addr := newTestServer(t, Settings{})
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	_, err = conn.Write([]byte{
+		0x00, 0x00, 0x00, 0x10,
+		0x00, 0x00, 0x09, 0x99,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x07,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	p, err := pdu.Parse(conn)
+	require.NoError(t, err)
+	require.True(t, p.IsGNack())
+	require.Equal(t, data.ESME_RINVCMDID, p.GetHeader().CommandStatus)
+	require.Equal(t, int32(7), p.GetSequenceNumber())
+}
+
+func TestCloseUnbindsSessions(t *testing.T) {
+	bound := make(chan *Session, 1)
+	srv, addr := newTestServer(t, Settings{
+		OnBound: func(s *Session) {
+			bound <- s
+		},
+	})
+
+	closed := make(chan smpp.State, 1)
+	session, err := smpp.NewSession(
+		smpp.RXConnector(smpp.NonTLSDialer, smpp.Auth{SMSC: addr}),
+		smpp.Settings{
+			ReadTimeout: 2 * time.Second,
+			OnClosed: func(state smpp.State) {
+				closed <- state
+			},
+		}, -1)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+
+	<-bound
+	require.NoError(t, srv.Close())
+	require.Empty(t, srv.Sessions())
+
+	select {
+	case state := <-closed:
+		require.Contains(t, []smpp.State{smpp.UnbindClosing, smpp.InvalidStreaming}, state)
+	case <-time.After(2 * time.Second):
+		t.Fatal("ESME is not unbound")
+	}
+}
+
+func TestCloseUnboundConnections(t *testing.T) {
+	srv, addr := newTestServer(t, Settings{})
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// wait connection is served
+	require.Eventually(t, func() bool {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		return len(srv.accepted) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Close()
+	}()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close waits for unbound connection")
+	}
+
+	// connection accepted after Close is refused
+	client, server := net.Pipe()
+	srv.ServeConn(server)
+	_, err = client.Read(make([]byte, 1))
+	require.Error(t, err)
+}
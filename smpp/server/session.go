@@ -0,0 +1,244 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/sujit-baniya/protocol/smpp"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+var (
+	// ErrNotBound indicates session is not bound yet, or already closed.
+	ErrNotBound = fmt.Errorf("session is not bound, can not send PDU to ESME")
+)
+
+const (
+	open int32 = iota
+	bound
+	closed
+)
+
+// Session represents a connection accepted from an ESME.
+type Session struct {
+	// ID is unique identifier of session.
+	ID string
+
+	// SystemID is system_id which ESME used to bind.
+	SystemID string
+
+	// SystemType is system_type which ESME used to bind.
+	SystemType string
+
+	// BindingType indicates how ESME is bound: Transmitter, Receiver or Transceiver.
+	BindingType pdu.BindingType
+
+	// InterfaceVersion is interface version negotiated with ESME, the lower of
+	// requested interface_version and Settings.InterfaceVersion.
+	InterfaceVersion byte
+
+	server *Server
+	conn   *smpp.Connection
+	state  int32
+
+	// registered is only accessed by serving goroutine
+	registered bool
+}
+
+func newSession(server *Server, conn net.Conn) *Session {
+	return &Session{
+		ID:     xid.New().String(),
+		server: server,
+		conn:   smpp.NewConnection(conn),
+	}
+}
+
+// RemoteAddr returns address of ESME.
+func (s *Session) RemoteAddr() net.Addr {
+	return s.conn.RemoteAddr()
+}
+
+// IsBound returns true if ESME is bound.
+func (s *Session) IsBound() bool {
+	return atomic.LoadInt32(&s.state) == bound
+}
+
+// Submit a PDU to ESME, i.e. deliver_sm or response to ESME request.
+// Request PDU is assigned with next sequence number of the session.
+func (s *Session) Submit(p pdu.PDU) (err error) {
+	if !s.IsBound() {
+		return ErrNotBound
+	}
+	_, err = s.write(p)
+	return
+}
+
+// Close tries to unbind ESME and closes underlying connection.
+func (s *Session) Close() (err error) {
+	if prev := atomic.SwapInt32(&s.state, closed); prev != closed {
+		if prev == bound {
+			_, _ = s.write(pdu.NewUnbind())
+		}
+		err = s.conn.Close()
+	}
+	return
+}
+
+func (s *Session) write(p pdu.PDU) (n int, err error) {
+	if s.server.settings.WriteTimeout > 0 {
+		err = s.conn.SetWriteTimeout(s.server.settings.WriteTimeout)
+	}
+
+	if err == nil {
+		s.conn.AssignSequenceNumber(p)
+		n, err = s.conn.WritePDU(p)
+	}
+	return
+}
+
+func (s *Session) serve() {
+	defer func() {
+		atomic.StoreInt32(&s.state, closed)
+		_ = s.conn.Close()
+
+		if s.registered {
+			s.server.unregister(s)
+			if s.server.settings.OnClosed != nil {
+				s.server.settings.OnClosed(s)
+			}
+		}
+	}()
+
+	settings := s.server.settings
+	if settings.BindTimeout > 0 {
+		if err := s.conn.SetReadTimeout(settings.BindTimeout); err != nil {
+			return
+		}
+	}
+
+	for {
+		p, err := pdu.Parse(s.conn)
+		if err != nil {
+			if atomic.LoadInt32(&s.state) == bound && settings.OnReceivingError != nil {
+				settings.OnReceivingError(s, err)
+			}
+
+			// unknown command does not damage following PDU(s)
+			var unknown *pdu.UnknownCommandError
+			if errors.As(err, &unknown) {
+				if unknown.Header.CommandID&data.GENERIC_NACK == 0 {
+					s.nack(unknown.Header.SequenceNumber, data.ESME_RINVCMDID)
+				}
+				continue
+			}
+			return
+		}
+
+		if settings.ReadTimeout > 0 && atomic.LoadInt32(&s.state) == bound {
+			if err = s.conn.SetReadTimeout(settings.ReadTimeout); err != nil {
+				return
+			}
+		}
+
+		if s.handleOrClose(p) {
+			return
+		}
+	}
+}
+
+func (s *Session) handleOrClose(p pdu.PDU) (closing bool) {
+	if req, ok := p.(*pdu.BindRequest); ok {
+		return s.bind(req)
+	}
+
+	if atomic.LoadInt32(&s.state) != bound {
+		s.nack(p.GetSequenceNumber(), data.ESME_RINVBNDSTS)
+		return
+	}
+
+	switch pp := p.(type) {
+	case *pdu.EnquireLink:
+		_, _ = s.write(pp.GetResponse())
+
+	case *pdu.Unbind:
+		_, _ = s.write(pp.GetResponse())
+		closing = true
+
+	case *pdu.UnbindResp:
+		closing = true
+
+	default:
+		if s.server.settings.OnPDU != nil {
+			s.server.settings.OnPDU(s, p)
+		} else if p.CanResponse() {
+			_, _ = s.write(p.GetResponse())
+		}
+	}
+	return
+}
+
+func (s *Session) nack(sequence int32, status data.CommandStatusType) {
+	nack := pdu.NewGenericNack().(*pdu.GenericNack)
+	nack.CommandStatus = status
+	nack.SetSequenceNumber(sequence)
+	_, _ = s.write(nack)
+}
+
+func (s *Session) bind(req *pdu.BindRequest) (closing bool) {
+	resp := req.GetResponse().(*pdu.BindResp)
+	resp.SystemID = s.server.settings.SystemID
+
+	if atomic.LoadInt32(&s.state) != open {
+		resp.CommandStatus = data.ESME_RALYBND
+		_, _ = s.write(resp)
+		return
+	}
+
+	// SMSC v3.3 does not support optional parameters
+	if req.InterfaceVersion >= data.SMPP_V34 {
+		resp.RegisterOptionalParam(pdu.Field{Tag: pdu.TagScInterfaceVersion, Data: []byte{s.server.interfaceVersion()}})
+	}
+
+	if resp.CommandStatus = s.server.authenticate(req); resp.CommandStatus != data.ESME_ROK {
+		_, _ = s.write(resp)
+		closing = true
+		return
+	}
+
+	s.SystemID = req.SystemID
+	s.SystemType = req.SystemType
+	s.BindingType = req.BindingType
+	s.InterfaceVersion = req.InterfaceVersion
+	if supported := s.server.interfaceVersion(); supported < s.InterfaceVersion {
+		s.InterfaceVersion = supported
+	}
+
+	if !atomic.CompareAndSwapInt32(&s.state, open, bound) {
+		closing = true
+		return
+	}
+
+	if _, err := s.write(resp); err != nil {
+		closing = true
+		return
+	}
+
+	settings := s.server.settings
+	if settings.ReadTimeout > 0 {
+		_ = s.conn.SetReadTimeout(settings.ReadTimeout)
+	} else {
+		_ = s.conn.SetReadDeadline(time.Time{})
+	}
+
+	s.server.register(s)
+	s.registered = true
+	if settings.OnBound != nil {
+		settings.OnBound(s)
+	}
+	return
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"github.com/rs/xid"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
 	"golang.org/x/time/rate"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -14,17 +16,25 @@ import (
 type Session struct {
 	ID               string
 	c                Connector
-	closed           bool
 	originalOnClosed func(State)
+	onStateChange    SessionEventCallback
+	logger           Logger
 	settings         Settings
 
 	rebindingInterval time.Duration
+	rebindPolicy      RebindPolicy
+	outbound          *outboundBuffer
+	wg                sync.WaitGroup
 
 	trx       atomic.Value // transceivable
+	sequence  SequenceGenerator
 	throttle  *rate.Limiter
 	rwctx     context.Context
 	lmctx     context.Context
+	ctx       context.Context // cancelled by Close, aborts rebinding
+	cancel    context.CancelFunc
 	state     int32
+	lifecycle int32 // SessionState
 	rebinding int32
 }
 
@@ -35,46 +45,100 @@ type Session struct {
 //
 // `rebindingInterval` indicates duration that Session has to wait before rebinding again.
 //
-// Setting `rebindingInterval <= 0` will disable `auto-rebind` functionality.
+// Setting `rebindingInterval <= 0` will disable `auto-rebind` functionality,
+// unless Settings.RebindPolicy is set.
 func NewSession(c Connector, settings Settings, rebindingInterval time.Duration) (session *Session, err error) {
+	return NewSessionContext(context.Background(), c, settings, rebindingInterval)
+}
+
+// NewSessionContext is like NewSession, but initial dialing and binding are aborted once ctx is done.
+//
+// The ctx does not affect the session once created, rebinding is stopped by Close.
+func NewSessionContext(ctx context.Context, c Connector, settings Settings, rebindingInterval time.Duration) (session *Session, err error) {
 	if settings.ReadTimeout <= 0 || settings.ReadTimeout <= settings.EnquireLink {
 		return nil, fmt.Errorf("invalid settings: ReadTimeout must greater than max(0, EnquireLink)")
 	}
 
-	conn, err := c.Connect()
-	if err == nil {
-		session = &Session{
-			ID:                xid.New().String(),
-			c:                 c,
-			rebindingInterval: rebindingInterval,
-			originalOnClosed:  settings.OnClosed,
-		}
+	session = &Session{
+		ID:                xid.New().String(),
+		c:                 c,
+		rebindingInterval: rebindingInterval,
+		rebindPolicy:      settings.RebindPolicy,
+		originalOnClosed:  settings.OnClosed,
+		onStateChange:     settings.OnStateChange,
+		sequence:          settings.SequenceGenerator,
+	}
+	session.logger = withFields(settings.Logger, "session_id", session.ID)
+	settings.Logger = session.logger
+	settings.sessionID = session.ID
+	if settings.Metrics == nil {
+		settings.Metrics = NewMemoryMetrics(nil)
+	}
+	if settings.Tracer != nil && settings.receipts == nil {
+		settings.receipts = newReceiptLinks(DefaultTraceReceiptLinks)
+	}
+	if settings.AdaptiveRate != nil && settings.adaptive == nil {
+		settings.adaptive = newAdaptiveRate(*settings.AdaptiveRate)
+	}
+	session.emit(StateConnecting, StateConnecting, "", nil)
+
+	conn, err := c.ConnectContext(session.connecting(ctx))
+	if err != nil {
+		session.setState(StateClosed, "", err)
+		return nil, err
+	}
+
+	session.ctx, session.cancel = context.WithCancel(context.Background())
+	if session.sequence == nil {
+		session.sequence = NewSequenceCounter(0)
+	}
+
+	if session.rebindPolicy == nil {
+		session.rebindPolicy = FixedRebindPolicy(rebindingInterval)
+	}
+
+	if settings.RebindPolicy != nil || rebindingInterval > 0 {
+		newSettings := settings
+		newSettings.OnClosed = func(state State) {
+			switch state {
+			case ExplicitClosing:
+				return
 
-		if rebindingInterval > 0 {
-			newSettings := settings
-			newSettings.OnClosed = func(state State) {
-				switch state {
-				case ExplicitClosing:
-					return
-
-				default:
-					if session.originalOnClosed != nil {
-						session.originalOnClosed(state)
-					}
-					session.rebind()
+			default:
+				session.setState(StateRebinding, "", connectionLost(state))
+				if session.originalOnClosed != nil {
+					session.originalOnClosed(state)
 				}
+				session.rebind()
 			}
-			session.settings = newSettings
-		} else {
-			session.settings = settings
 		}
-		if session.settings.Throttle != 0 {
-			rateLimiter := rate.NewLimiter(rate.Limit(settings.Throttle), 1)
-			session.rwctx = context.Background()
-			session.throttle = rateLimiter
+		session.settings = newSettings
+	} else {
+		newSettings := settings
+		newSettings.OnClosed = func(state State) {
+			if state != ExplicitClosing {
+				session.setState(StateClosed, "", connectionLost(state))
+			}
+			if session.originalOnClosed != nil {
+				session.originalOnClosed(state)
+			}
 		}
-		// bind to session
-		session.trx.Store(newTransceivable(conn, session.settings))
+		session.settings = newSettings
+	}
+	if session.settings.Throttle != 0 {
+		rateLimiter := rate.NewLimiter(rate.Limit(settings.Throttle), 1)
+		session.rwctx = context.Background()
+		session.throttle = rateLimiter
+	}
+	// bind to session
+	conn.SetSequenceGenerator(session.sequence)
+	session.trx.Store(newTransceivable(conn, session.settings))
+	session.setState(StateBound, conn.systemID, nil)
+
+	if settings.OutboundBuffer != nil {
+		session.outbound = newOutboundBuffer(*settings.OutboundBuffer)
+		session.wg.Add(1)
+		go session.forward()
 	}
 	return
 }
@@ -99,6 +163,11 @@ func (s *Session) Transceiver() Transceiver {
 	return s.bound()
 }
 
+// AsyncSubmitter returns bound Transceiver as AsyncSubmitter.
+func (s *Session) AsyncSubmitter() AsyncSubmitter {
+	return s.bound()
+}
+
 // Close session.
 func (s *Session) Close() (err error) {
 	if s == nil {
@@ -106,22 +175,131 @@ func (s *Session) Close() (err error) {
 		return
 	}
 	if atomic.CompareAndSwapInt32(&s.state, Alive, Closed) {
+		s.setState(StateClosed, "", nil)
+		s.cancel()
 		err = s.close()
+
+		if s.outbound != nil {
+			s.wg.Wait()
+			s.outbound.close()
+		}
 	}
 	return
 }
 
+// Shutdown closes session gracefully:
+//   - new submits are rejected with ErrConnectionClosing,
+//   - PDUs queued in Settings.OutboundBuffer are forwarded,
+//   - responses of pending requests are waited for,
+//   - unbind is exchanged with SMSC, then connection is closed.
+//
+// Once ctx is done, remaining steps are skipped and the session is closed immediately.
+// Shutdown returns requests left without response along with queued PDUs which are never forwarded,
+// and ctx error if ctx is done before the unbind_resp.
+//
+// If session is rebinding, queued PDUs wait for it until ctx is done.
+func (s *Session) Shutdown(ctx context.Context) (unacknowledged []pdu.PDU, err error) {
+	if !atomic.CompareAndSwapInt32(&s.state, Alive, Closed) {
+		return nil, ErrConnectionClosing
+	}
+	s.setState(StateUnbinding, "", nil)
+	defer func() {
+		s.setState(StateClosed, "", err)
+	}()
+
+	if s.outbound != nil {
+		s.outbound.stop()
+		err = s.outbound.flush(ctx)
+	}
+
+	if t := s.bound(); t != nil {
+		pending, shutdownErr := t.shutdown(ctx)
+		if err == nil {
+			err = shutdownErr
+		}
+		unacknowledged = append(unacknowledged, pending...)
+	}
+
+	s.cancel()
+	if s.outbound != nil {
+		s.wg.Wait()
+		unacknowledged = append(unacknowledged, s.outbound.close()...)
+	}
+	return
+}
+
+// Submit a PDU via bound transceiver.
+//
+// With Settings.OutboundBuffer, the PDU is queued and forwarded in background, surviving rebinds.
+// Failure of forwarding is reported to OutboundBufferSettings.OnDrop.
+func (s *Session) Submit(p pdu.PDU) error {
+	return s.SubmitContext(context.Background(), p)
+}
+
+// SubmitContext is like Submit, but gives up once ctx is done.
+func (s *Session) SubmitContext(ctx context.Context, p pdu.PDU) error {
+	if s.outbound == nil {
+		return s.bound().SubmitContext(ctx, p)
+	}
+	return s.outbound.push(ctx, p)
+}
+
+// forward queued PDUs to bound transceiver until session is closed.
+func (s *Session) forward() {
+	defer s.wg.Done()
+
+	for {
+		p, ok := s.outbound.pop(s.ctx)
+		if !ok {
+			return
+		}
+		s.forwardPDU(p)
+		s.outbound.done()
+	}
+}
+
+// forwardPDU submits PDU, waiting for rebinding if transceiver is closing.
+// Request is assigned with fresh sequence number by each transceiver.
+func (s *Session) forwardPDU(p pdu.PDU) {
+	for {
+		rebound := s.outbound.boundSignal()
+
+		err := s.bound().submitRequest(s.ctx, p, s.outbound.lost)
+		if err == nil {
+			return
+		}
+
+		if errors.Is(err, ErrConnectionClosing) {
+			select {
+			case <-rebound:
+				continue
+			case <-s.ctx.Done():
+			}
+		}
+
+		if s.ctx.Err() != nil {
+			err = ErrConnectionClosing
+		}
+		s.outbound.drop(p, err)
+		return
+	}
+}
+
 func (s *Session) close() (err error) {
 	if b := s.bound(); b != nil {
-		s.closed = true
 		err = b.Close()
 	}
 	return
 }
 
 func (s *Session) Wait() error {
+	return s.WaitContext(s.rwctx)
+}
+
+// WaitContext waits for session throttle, see Settings.Throttle, until ctx is done.
+func (s *Session) WaitContext(ctx context.Context) error {
 	if s.throttle != nil {
-		return s.throttle.Wait(s.rwctx)
+		return s.throttle.Wait(ctx)
 	}
 	return nil
 }
@@ -129,27 +307,143 @@ func (s *Session) Wait() error {
 func (s *Session) rebind() {
 	if atomic.CompareAndSwapInt32(&s.rebinding, 0, 1) {
 		_ = s.close()
+		if s.State() != StateRebinding {
+			s.setState(StateRebinding, "", nil)
+		}
 
-		for atomic.LoadInt32(&s.state) == Alive {
-			conn, err := s.c.Connect()
+		start := time.Now()
+		var lastErr error
+
+		for attempt := 0; atomic.LoadInt32(&s.state) == Alive; attempt++ {
+			delay, ok := s.rebindPolicy.Next(attempt, time.Since(start), lastErr)
+			if !ok {
+				s.logger.Error("rebinding gave up", "attempts", attempt, "error", lastErr)
+				s.setState(StateClosed, "", lastErr)
+				_ = s.Close()
+				if s.settings.OnRebindGiveUp != nil {
+					s.settings.OnRebindGiveUp(attempt, lastErr)
+				}
+				break
+			}
+
+			s.logger.Info("rebinding", "attempt", attempt+1, "delay", delay)
+			if delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-s.ctx.Done():
+				}
+			}
+			if s.ctx.Err() != nil {
+				break
+			}
+
+			if s.settings.OnRebindAttempt != nil {
+				s.settings.OnRebindAttempt(attempt + 1)
+			}
+
+			s.setState(StateConnecting, "", nil)
+			conn, err := s.c.ConnectContext(s.connecting(s.ctx))
 			if err != nil {
+				if s.ctx.Err() != nil {
+					break
+				}
+
+				lastErr = err
+				s.settings.Metrics.RebindAttempt(s.ID, err)
+				s.setState(StateRebinding, "", err)
 				if s.settings.OnRebindingError != nil {
 					s.settings.OnRebindingError(err)
 				}
-				time.Sleep(s.rebindingInterval)
-			} else {
-				// bind to session
-				s.trx.Store(newTransceivable(conn, s.settings))
+				continue
+			}
 
-				// reset rebinding state
-				atomic.StoreInt32(&s.rebinding, 0)
+			// session is closed while binding
+			if s.ctx.Err() != nil {
+				_ = conn.Close()
+				break
+			}
 
-				return
+			// bind to session
+			conn.SetSequenceGenerator(s.sequence)
+			s.trx.Store(newTransceivable(conn, s.settings))
+			s.settings.Metrics.RebindAttempt(s.ID, nil)
+			s.setState(StateBound, conn.systemID, nil)
+			if s.outbound != nil {
+				s.outbound.rebound()
 			}
+
+			// reset rebinding state
+			atomic.StoreInt32(&s.rebinding, 0)
+
+			if s.settings.OnRebound != nil {
+				s.settings.OnRebound(attempt+1, time.Since(start))
+			}
+			return
 		}
 	}
 }
 
+// SequenceGenerator returns generator numbering requests of the session.
+// Metrics returns instrumentation of session, MemoryMetrics unless Settings.Metrics is set.
+func (s *Session) Metrics() Metrics {
+	return s.settings.Metrics
+}
+
+func (s *Session) SequenceGenerator() SequenceGenerator {
+	return s.sequence
+}
+
+// IsClosed returns true once session is closed for good.
 func (s *Session) IsClosed() bool {
-	return s.closed
+	return s.State() == StateClosed
+}
+
+// State returns lifecycle state of the session.
+func (s *Session) State() SessionState {
+	return SessionState(atomic.LoadInt32(&s.lifecycle))
+}
+
+// setState transits to given state and notifies Settings.OnStateChange. StateClosed is final.
+func (s *Session) setState(state SessionState, systemID string, err error) {
+	for {
+		previous := s.State()
+		if previous == StateClosed {
+			return
+		}
+
+		if atomic.CompareAndSwapInt32(&s.lifecycle, int32(previous), int32(state)) {
+			s.emit(state, previous, systemID, err)
+			return
+		}
+	}
+}
+
+func (s *Session) emit(state, previous SessionState, systemID string, err error) {
+	args := []interface{}{"state", state.String(), "previous", previous.String()}
+	if systemID != "" {
+		args = append(args, "system_id", systemID)
+	}
+	if err != nil {
+		s.logger.Warn("session state changed", append(args, "error", err)...)
+	} else {
+		s.logger.Info("session state changed", args...)
+	}
+
+	if s.onStateChange != nil {
+		s.onStateChange(SessionEvent{
+			SessionID: s.ID,
+			State:     state,
+			Previous:  previous,
+			Time:      time.Now(),
+			SystemID:  systemID,
+			Err:       err,
+		})
+	}
+}
+
+// connecting returns ctx for connector, transiting to StateBinding once bind request is issued.
+func (s *Session) connecting(ctx context.Context) context.Context {
+	return withBindingHook(ctx, func() {
+		s.setState(StateBinding, "", nil)
+	})
 }
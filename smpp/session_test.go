@@ -1,9 +1,15 @@
 package smpp
 
 import (
+	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,3 +29,321 @@ func TestInvalidSessionSettings(t *testing.T) {
 		}, 2*time.Second)
 	require.Error(t, err)
 }
+
+func TestSessionFaultInjection(t *testing.T) {
+	newSession := func(t *testing.T, smsc *smsctest.Server, settings Settings) *Session {
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), settings, 100*time.Millisecond)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = session.Close()
+		})
+		return session
+	}
+
+	t.Run("Unbind", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		closed := make(chan State, 1)
+		newSession(t, smsc, Settings{
+			ReadTimeout: time.Second,
+			OnClosed: func(state State) {
+				select {
+				case closed <- state:
+				default:
+				}
+			},
+		})
+
+		require.NoError(t, smsc.Unbind())
+		require.Equal(t, UnbindClosing, <-closed)
+
+		// unbind is responded, not sent back
+		require.Eventually(t, func() bool {
+			return len(smsc.ReceivedOf(data.UNBIND_RESP)) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.Empty(t, smsc.ReceivedOf(data.UNBIND))
+
+		// rebound
+		require.Eventually(t, func() bool {
+			return len(smsc.ReceivedOf(data.BIND_TRANSCEIVER)) == 2 && smsc.Bound() == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("MalformedPDU", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		closed := make(chan State, 1)
+		newSession(t, smsc, Settings{
+			ReadTimeout: time.Second,
+			OnClosed: func(state State) {
+				select {
+				case closed <- state:
+				default:
+				}
+			},
+		})
+
+		require.NoError(t, smsc.SendMalformed())
+		require.Equal(t, InvalidStreaming, <-closed)
+	})
+
+	t.Run("Stall", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		closed := make(chan State, 1)
+		session := newSession(t, smsc, Settings{
+			ReadTimeout: 300 * time.Millisecond,
+			EnquireLink: 100 * time.Millisecond,
+			OnClosed: func(state State) {
+				select {
+				case closed <- state:
+				default:
+				}
+			},
+		})
+
+		smsc.Stall()
+		require.Equal(t, InvalidStreaming, <-closed)
+		smsc.Resume()
+
+		require.Eventually(t, func() bool {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			_, err := session.Transceiver().SubmitResp(ctx, pdu.NewEnquireLink())
+			return err == nil
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("GenericNack", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		nacks := make(chan pdu.PDU, 1)
+		newSession(t, smsc, Settings{
+			ReadTimeout: time.Second,
+			OnPDU: func(p pdu.PDU, _ bool) {
+				t.Error("unmatched response is passed to OnPDU")
+			},
+			OnUnmatchedResponse: func(p pdu.PDU) {
+				if p.IsGNack() {
+					select {
+					case nacks <- p:
+					default:
+					}
+				}
+			},
+		})
+
+		require.NoError(t, smsc.GenericNack(data.ESME_RINVCMDLEN))
+		require.Equal(t, data.ESME_RINVCMDLEN, (<-nacks).GetHeader().CommandStatus)
+	})
+
+	t.Run("SubmitStatusAndDeliveryReceipt", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{
+			MessageID: func(pdu.PDU) string {
+				return "abc"
+			},
+			DeliveryReceipt:      true,
+			DeliveryReceiptDelay: 50 * time.Millisecond,
+		})
+		defer smsc.Close()
+
+		receipts := make(chan *pdu.DeliverSM, 1)
+		session := newSession(t, smsc, Settings{
+			ReadTimeout: time.Second,
+			OnPDU: func(p pdu.PDU, _ bool) {
+				if pd, ok := p.(*pdu.DeliverSM); ok {
+					select {
+					case receipts <- pd:
+					default:
+					}
+				}
+			},
+		})
+
+		resp, err := session.Transceiver().SubmitResp(context.Background(), newSubmitSM("123456"))
+		require.NoError(t, err)
+		require.Equal(t, "abc", resp.(*pdu.SubmitSMResp).MessageID)
+
+		select {
+		case receipt := <-receipts:
+			r, err := pdu.ParseDeliveryReceipt(receipt)
+			require.NoError(t, err)
+			require.Equal(t, "abc", r.MessageID)
+			require.Equal(t, pdu.MessageStateDelivered, r.State)
+
+		case <-time.After(time.Second):
+			t.Fatal("delivery receipt is not received")
+		}
+
+		require.Len(t, smsc.ReceivedOf(data.SUBMIT_SM), 1)
+	})
+}
+
+// blockingConnector binds once, then blocks until ctx of ConnectContext is done.
+type blockingConnector struct {
+	Connector
+	calls   int32
+	aborted chan error
+}
+
+func (c *blockingConnector) ConnectContext(ctx context.Context) (*Connection, error) {
+	if atomic.AddInt32(&c.calls, 1) == 1 {
+		return c.Connector.ConnectContext(ctx)
+	}
+
+	<-ctx.Done()
+	c.aborted <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestSessionContext(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := NewSessionContext(ctx, TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+			ReadTimeout: time.Second,
+		}, time.Second)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Close aborts rebinding", func(t *testing.T) {
+		connector := &blockingConnector{
+			Connector: TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}),
+			aborted:   make(chan error, 1),
+		}
+
+		session, err := NewSessionContext(context.Background(), connector, Settings{
+			ReadTimeout: time.Second,
+		}, 10*time.Millisecond)
+		require.NoError(t, err)
+
+		smsc.CloseConnections()
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&connector.calls) == 2
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, session.Close())
+		select {
+		case err = <-connector.aborted:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("rebinding is not aborted")
+		}
+	})
+}
+
+func TestSessionShutdown(t *testing.T) {
+	newSession := func(t *testing.T, smsc *smsctest.Server, settings Settings) *Session {
+		settings.ReadTimeout = time.Second
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), settings, 10*time.Millisecond)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = session.Close()
+		})
+		return session
+	}
+
+	t.Run("drains pending requests", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		closed := make(chan State, 10)
+		responses := make(chan pdu.PDU, 10)
+		session := newSession(t, smsc, Settings{
+			OnPDU: func(p pdu.PDU, _ bool) {
+				responses <- p
+			},
+			OnClosed: func(state State) {
+				closed <- state
+			},
+			OutboundBuffer: &OutboundBufferSettings{},
+		})
+
+		smsc.StallFor(100 * time.Millisecond)
+		require.NoError(t, session.Transceiver().Submit(newSubmitSM("esme")))
+		require.NoError(t, session.Submit(newSubmitSM("esme")))
+		async := session.AsyncSubmitter().SubmitAsync(newSubmitSM("esme"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		unacknowledged, err := session.Shutdown(ctx)
+		require.NoError(t, err)
+		require.Empty(t, unacknowledged)
+
+		require.NoError(t, (<-async).Err)
+		require.Len(t, responses, 2)
+		require.Len(t, smsc.ReceivedOf(data.UNBIND), 1)
+
+		require.ErrorIs(t, session.Submit(newSubmitSM("esme")), ErrConnectionClosing)
+		require.ErrorIs(t, session.Transceiver().Submit(newSubmitSM("esme")), ErrConnectionClosing)
+
+		// neither rebinding nor unexpected closing
+		time.Sleep(50 * time.Millisecond)
+		require.Len(t, smsc.ReceivedOf(data.BIND_TRANSCEIVER), 1)
+		require.Empty(t, closed)
+	})
+
+	t.Run("reports unacknowledged", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		session := newSession(t, smsc, Settings{})
+
+		smsc.Stall()
+		defer smsc.Resume()
+
+		submitted := newSubmitSM("esme")
+		require.NoError(t, session.Transceiver().Submit(submitted))
+		async := session.AsyncSubmitter().SubmitAsync(newSubmitSM("esme"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		unacknowledged, err := session.Shutdown(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Len(t, unacknowledged, 2)
+		require.Same(t, submitted, unacknowledged[0])
+		require.ErrorIs(t, (<-async).Err, ErrConnectionClosing)
+
+		_, err = session.Shutdown(context.Background())
+		require.ErrorIs(t, err, ErrConnectionClosing)
+	})
+}
+
+// plainTransceiver implements Transceiver only, like implementations predating AsyncSubmitter.
+type plainTransceiver struct{}
+
+func (plainTransceiver) Close() error                                         { return nil }
+func (plainTransceiver) SubmitResp(context.Context, pdu.PDU) (pdu.PDU, error) { return nil, nil }
+func (plainTransceiver) Submit(pdu.PDU) error                                 { return nil }
+func (plainTransceiver) SystemID() string                                     { return "" }
+
+func TestAsyncSubmitter(t *testing.T) {
+	var trx Transceiver = plainTransceiver{}
+	_, ok := trx.(AsyncSubmitter)
+	require.False(t, ok)
+
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+		ReadTimeout: time.Second,
+	}, time.Second)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+
+	_, ok = session.Transceiver().(AsyncSubmitter)
+	require.True(t, ok)
+	require.NoError(t, (<-session.AsyncSubmitter().SubmitAsync(newSubmitSM("esme"))).Err)
+}
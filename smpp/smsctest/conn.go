@@ -0,0 +1,181 @@
+package smsctest
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/coding"
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+const receiptDateFormat = "0601021504"
+
+type conn struct {
+	server *Server
+	conn   net.Conn
+	mu     sync.Mutex
+	bound  int32
+	closed int32
+	done   chan struct{}
+}
+
+func (c *conn) isBound() bool {
+	return atomic.LoadInt32(&c.bound) == 1
+}
+
+func (c *conn) close() {
+	if atomic.CompareAndSwapInt32(&c.closed, 0, 1) {
+		close(c.done)
+		_ = c.conn.Close()
+	}
+}
+
+func (c *conn) write(b []byte) (n int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	return c.conn.Write(b)
+}
+
+func (c *conn) writePDU(p pdu.PDU) {
+	buf := pdu.NewBuffer(nil)
+	p.Marshal(buf)
+	_, _ = c.write(buf.Bytes())
+}
+
+func (c *conn) respond(p pdu.PDU) {
+	if stalled := c.server.stall(); stalled != nil {
+		select {
+		case <-stalled:
+		case <-c.done:
+			return
+		}
+	}
+	c.writePDU(p)
+}
+
+func (c *conn) loop() {
+	for {
+		p, err := pdu.Parse(c.conn)
+		if err != nil {
+			return
+		}
+
+		c.server.record(p)
+		if c.handleOrClose(p) {
+			return
+		}
+	}
+}
+
+func (c *conn) handleOrClose(p pdu.PDU) (closing bool) {
+	switch pd := p.(type) {
+	case *pdu.BindRequest:
+		resp := pd.GetResponse().(*pdu.BindResp)
+		resp.SystemID = c.server.config.SystemID
+		if v := c.server.config.InterfaceVersion; v != 0 {
+			resp.RegisterOptionalParam(pdu.Field{Tag: pdu.TagScInterfaceVersion, Data: []byte{v}})
+		}
+		if c.server.config.Authenticate != nil {
+			resp.CommandStatus = c.server.config.Authenticate(pd)
+		}
+
+		if resp.CommandStatus == data.ESME_ROK {
+			atomic.StoreInt32(&c.bound, 1)
+		} else {
+			closing = true
+		}
+		c.respond(resp)
+
+	case *pdu.Unbind:
+		c.respond(pd.GetResponse())
+		closing = true
+
+	case *pdu.UnbindResp:
+		closing = true
+
+	case *pdu.SubmitSM:
+		resp := pd.GetResponse().(*pdu.SubmitSMResp)
+		if resp.CommandStatus = c.server.submitStatus(pd); resp.CommandStatus == data.ESME_ROK {
+			resp.MessageID = c.server.nextMessageID(pd)
+		}
+		c.respond(resp)
+
+		if resp.CommandStatus == data.ESME_ROK && c.server.config.DeliveryReceipt &&
+			pd.RegisteredDelivery&data.SM_SMSC_RECEIPT_MASK != data.SM_SMSC_RECEIPT_NOT_REQUESTED {
+			c.scheduleReceipt(pd, resp.MessageID, time.Now())
+		}
+
+	case *pdu.SubmitMulti:
+		resp := pd.GetResponse().(*pdu.SubmitMultiResp)
+		if resp.CommandStatus = c.server.submitStatus(pd); resp.CommandStatus == data.ESME_ROK {
+			resp.MessageID = c.server.nextMessageID(pd)
+		}
+		c.respond(resp)
+
+	case *pdu.DataSM:
+		resp := pd.GetResponse().(*pdu.DataSMResp)
+		if resp.CommandStatus = c.server.submitStatus(pd); resp.CommandStatus == data.ESME_ROK {
+			resp.MessageID = c.server.nextMessageID(pd)
+		}
+		c.respond(resp)
+
+	case *pdu.BroadcastSM:
+		resp := pd.GetResponse().(*pdu.BroadcastSMResp)
+		if resp.CommandStatus = c.server.submitStatus(pd); resp.CommandStatus == data.ESME_ROK {
+			resp.MessageID = c.server.nextMessageID(pd)
+		}
+		c.respond(resp)
+
+	default:
+		if p.CanResponse() {
+			c.respond(p.GetResponse())
+		}
+	}
+	return
+}
+
+func (c *conn) scheduleReceipt(req *pdu.SubmitSM, messageID string, submitted time.Time) {
+	stat := "DELIVRD"
+	if c.server.config.DeliveryReceiptStat != nil {
+		stat = c.server.config.DeliveryReceiptStat(req)
+	}
+
+	time.AfterFunc(c.server.config.DeliveryReceiptDelay, func() {
+		if atomic.LoadInt32(&c.closed) == 0 {
+			c.writePDU(newDeliveryReceipt(req, messageID, stat, submitted, time.Now()))
+		}
+	})
+}
+
+func newDeliveryReceipt(req *pdu.SubmitSM, messageID, stat string, submitted, done time.Time) *pdu.DeliverSM {
+	dlvrd := 0
+	if stat == "DELIVRD" {
+		dlvrd = 1
+	}
+
+	text, _ := req.Message.GetMessage()
+	if r := []rune(text); len(r) > 20 {
+		text = string(r[:20])
+	}
+
+	receipt := pdu.NewDeliverSM().(*pdu.DeliverSM)
+	receipt.SourceAddr = req.DestAddr
+	receipt.DestAddr = req.SourceAddr
+	receipt.EsmClass = data.SM_SMSC_DLV_RCPT_TYPE
+
+	receiptText := fmt.Sprintf(
+		"id:%s sub:001 dlvrd:%03d submit date:%s done date:%s stat:%s err:000 text:%s",
+		messageID, dlvrd, submitted.Format(receiptDateFormat), done.Format(receiptDateFormat), stat, text,
+	)
+	_ = receipt.Message.SetMessageWithEncoding(receiptText, coding.BestSafeCoding(receiptText))
+
+	receipt.RegisterOptionalParam(pdu.Field{Tag: pdu.TagReceiptedMessageID, Data: append([]byte(messageID), 0)})
+	receipt.RegisterOptionalParam(pdu.Field{Tag: pdu.TagMessageStateOption, Data: []byte{byte(pdu.ParseMessageState(stat))}})
+	return receipt
+}
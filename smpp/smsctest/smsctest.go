@@ -0,0 +1,354 @@
+// Package smsctest provides an in-process SMSC simulator for testing ESME code
+// without a live host.
+//
+// The simulator speaks only through package pdu so it can be used from tests
+// of package smpp itself:
+//
+//	smsc := smsctest.NewServer(smsctest.Config{})
+//	defer smsc.Close()
+//
+//	session, err := smpp.NewSession(
+//		smpp.TRXConnector(smsc.Dial, smpp.Auth{SMSC: smsc.Addr()}),
+//		settings, -1)
+package smsctest
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+// DefaultSystemID is system_id returned in bind_resp when Config.SystemID is empty.
+const DefaultSystemID = "SMSCTest"
+
+var errServerClosed = fmt.Errorf("smsctest: server closed")
+
+// Config of simulated SMSC.
+type Config struct {
+	// SystemID returned within bind_resp.
+	SystemID string
+
+	// Authenticate validates bind requests. Nil accepts all binds.
+	Authenticate func(req *pdu.BindRequest) data.CommandStatusType
+
+	// InterfaceVersion is sc_interface_version returned within bind_resp. Zero omits it.
+	InterfaceVersion byte
+
+	// MessageID generates message_id for submit_sm, submit_multi, data_sm and broadcast_sm.
+	// Nil generates sequential identifiers.
+	MessageID func(req pdu.PDU) string
+
+	// SubmitStatus decides command_status responded to submit_sm, submit_multi, data_sm and broadcast_sm.
+	// Nil always responds ESME_ROK.
+	SubmitStatus func(req pdu.PDU) data.CommandStatusType
+
+	// DeliveryReceipt enables delivery receipts for accepted submit_sm
+	// which requested SMSC delivery receipt.
+	DeliveryReceipt bool
+
+	// DeliveryReceiptDelay is delay between submit_sm_resp and its delivery receipt.
+	DeliveryReceiptDelay time.Duration
+
+	// DeliveryReceiptStat decides stat field of delivery receipt. Nil always reports DELIVRD.
+	DeliveryReceiptStat func(req *pdu.SubmitSM) string
+}
+
+// Server is a simulated SMSC.
+type Server struct {
+	config Config
+
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	conns    map[*conn]struct{}
+	received []Record
+
+	stalled chan struct{}
+
+	messageID  uint64
+	aliveState int32
+}
+
+// Record is a PDU received by simulated SMSC.
+type Record struct {
+	Time time.Time
+	PDU  pdu.PDU
+}
+
+// NewServer starts a simulated SMSC listening on a loopback address.
+// It panics if listening fails, like httptest.NewServer.
+func NewServer(config Config) *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("smsctest: failed to listen on a port: %v", err))
+	}
+
+	if config.SystemID == "" {
+		config.SystemID = DefaultSystemID
+	}
+
+	s := &Server{
+		config:   config,
+		listener: l,
+		conns:    make(map[*conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.accept()
+	}()
+
+	return s
+}
+
+// Addr returns listening address of simulated SMSC.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Dial connects to simulated SMSC over net.Pipe, ignoring addr.
+// It has the same signature as smpp.Dialer.
+func (s *Server) Dial(_ string) (net.Conn, error) {
+	client, server := net.Pipe()
+	if err := s.serve(server); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// Close closes all connections and stops simulated SMSC.
+func (s *Server) Close() {
+	// serve either sees server closed or registers connection before it is closed here
+	s.mu.Lock()
+	closing := atomic.CompareAndSwapInt32(&s.aliveState, 0, 1)
+	s.mu.Unlock()
+
+	if closing {
+		_ = s.listener.Close()
+		s.Resume()
+		s.CloseConnections()
+		s.wg.Wait()
+	}
+}
+
+// CloseConnections closes all connections abruptly, without unbind.
+func (s *Server) CloseConnections() {
+	for _, c := range s.connections() {
+		c.close()
+	}
+}
+
+// Bound returns number of bound connections.
+func (s *Server) Bound() (n int) {
+	for _, c := range s.connections() {
+		if c.isBound() {
+			n++
+		}
+	}
+	return
+}
+
+// Received returns all PDUs received so far, in receiving order.
+func (s *Server) Received() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := make([]Record, len(s.received))
+	copy(records, s.received)
+	return records
+}
+
+// ReceivedOf returns received PDUs with given command id.
+func (s *Server) ReceivedOf(id data.CommandIDType) (pdus []pdu.PDU) {
+	for _, r := range s.Received() {
+		if r.PDU.GetHeader().CommandID == id {
+			pdus = append(pdus, r.PDU)
+		}
+	}
+	return
+}
+
+// Reset clears received PDUs.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	s.received = nil
+	s.mu.Unlock()
+}
+
+// Send writes a PDU to all bound connections.
+func (s *Server) Send(p pdu.PDU) (err error) {
+	buf := pdu.NewBuffer(nil)
+	p.Marshal(buf)
+	return s.SendRaw(buf.Bytes())
+}
+
+// SendRaw writes raw bytes to all bound connections.
+func (s *Server) SendRaw(b []byte) (err error) {
+	for _, c := range s.connections() {
+		if c.isBound() {
+			if _, e := c.write(b); e != nil {
+				err = e
+			}
+		}
+	}
+	return
+}
+
+// Unbind sends unbind to all bound connections.
+func (s *Server) Unbind() error {
+	return s.Send(pdu.NewUnbind())
+}
+
+// GenericNack sends generic_nack with given status to all bound connections.
+func (s *Server) GenericNack(status data.CommandStatusType) error {
+	nack := pdu.NewGenericNack().(*pdu.GenericNack)
+	nack.CommandStatus = status
+	return s.Send(nack)
+}
+
+// SendMalformed sends a PDU with invalid command_length to all bound connections.
+func (s *Server) SendMalformed() error {
+	return s.SendRaw([]byte{
+		0x00, 0x00, 0x00, 0x08, // command_length < header size
+		0x00, 0x00, 0x00, 0x05,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x01,
+	})
+}
+
+// Outbind connects to ESME listening on addr and sends outbind with given credentials.
+// The connection is then served like an accepted one, waiting for bind_receiver.
+func (s *Server) Outbind(addr, systemID, password string) error {
+	if atomic.LoadInt32(&s.aliveState) != 0 {
+		return errServerClosed
+	}
+
+	nc, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	outbind := pdu.NewOutbind().(*pdu.Outbind)
+	outbind.SystemID = systemID
+	outbind.Password = password
+
+	buf := pdu.NewBuffer(nil)
+	outbind.Marshal(buf)
+	if _, err = nc.Write(buf.Bytes()); err != nil {
+		_ = nc.Close()
+		return err
+	}
+
+	return s.serve(nc)
+}
+
+// Stall holds responses to all requests until Resume is called.
+// Requests are still recorded when they are read.
+func (s *Server) Stall() {
+	s.mu.Lock()
+	if s.stalled == nil {
+		s.stalled = make(chan struct{})
+	}
+	s.mu.Unlock()
+}
+
+// StallFor stalls for duration d.
+func (s *Server) StallFor(d time.Duration) {
+	s.Stall()
+	time.AfterFunc(d, s.Resume)
+}
+
+// Resume responding after Stall.
+func (s *Server) Resume() {
+	s.mu.Lock()
+	if s.stalled != nil {
+		close(s.stalled)
+		s.stalled = nil
+	}
+	s.mu.Unlock()
+}
+
+func (s *Server) stall() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stalled
+}
+
+func (s *Server) accept() {
+	for {
+		nc, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		_ = s.serve(nc)
+	}
+}
+
+// serve handles nc until it is closed. The nc is closed right away if server is closed.
+func (s *Server) serve(nc net.Conn) error {
+	c := &conn{server: s, conn: nc, done: make(chan struct{})}
+
+	s.mu.Lock()
+	if atomic.LoadInt32(&s.aliveState) != 0 {
+		s.mu.Unlock()
+		_ = nc.Close()
+		return errServerClosed
+	}
+	s.conns[c] = struct{}{}
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.close()
+
+			s.mu.Lock()
+			delete(s.conns, c)
+			s.mu.Unlock()
+
+			s.wg.Done()
+		}()
+		c.loop()
+	}()
+	return nil
+}
+
+func (s *Server) connections() (conns []*conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conns = make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	return
+}
+
+func (s *Server) record(p pdu.PDU) {
+	s.mu.Lock()
+	s.received = append(s.received, Record{Time: time.Now(), PDU: p})
+	s.mu.Unlock()
+}
+
+func (s *Server) nextMessageID(req pdu.PDU) string {
+	if s.config.MessageID != nil {
+		return s.config.MessageID(req)
+	}
+	return strconv.FormatUint(atomic.AddUint64(&s.messageID, 1), 10)
+}
+
+func (s *Server) submitStatus(req pdu.PDU) data.CommandStatusType {
+	if s.config.SubmitStatus != nil {
+		return s.config.SubmitStatus(req)
+	}
+	return data.ESME_ROK
+}
@@ -0,0 +1,176 @@
+package smsctest
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+
+	"github.com/stretchr/testify/require"
+)
+
+func write(t *testing.T, c net.Conn, p pdu.PDU) {
+	buf := pdu.NewBuffer(nil)
+	p.Marshal(buf)
+	_, err := c.Write(buf.Bytes())
+	require.NoError(t, err)
+}
+
+func read(t *testing.T, c net.Conn) pdu.PDU {
+	require.NoError(t, c.SetReadDeadline(time.Now().Add(time.Second)))
+	p, err := pdu.Parse(c)
+	require.NoError(t, err)
+	return p
+}
+
+func bind(t *testing.T, s *Server, dial func(string) (net.Conn, error)) (net.Conn, *pdu.BindResp) {
+	c, err := dial(s.Addr())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+
+	req := pdu.NewBindRequest(pdu.Transceiver)
+	req.SystemID = "esme"
+	write(t, c, req)
+
+	resp, ok := read(t, c).(*pdu.BindResp)
+	require.True(t, ok)
+	require.Equal(t, req.GetSequenceNumber(), resp.GetSequenceNumber())
+	return c, resp
+}
+
+func TestBind(t *testing.T) {
+	s := NewServer(Config{
+		Authenticate: func(req *pdu.BindRequest) data.CommandStatusType {
+			if req.SystemID != "esme" {
+				return data.ESME_RINVSYSID
+			}
+			return data.ESME_ROK
+		},
+	})
+	defer s.Close()
+
+	dial := func(addr string) (net.Conn, error) {
+		return net.Dial("tcp", addr)
+	}
+
+	for _, d := range []func(string) (net.Conn, error){dial, s.Dial} {
+		_, resp := bind(t, s, d)
+		require.Equal(t, data.ESME_ROK, resp.CommandStatus)
+		require.Equal(t, DefaultSystemID, resp.SystemID)
+	}
+	require.Equal(t, 2, s.Bound())
+	require.Len(t, s.ReceivedOf(data.BIND_TRANSCEIVER), 2)
+}
+
+func TestDialClose(t *testing.T) {
+	s := NewServer(Config{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if conn, err := s.Dial(s.Addr()); err == nil {
+				_ = conn.Close()
+			}
+		}()
+	}
+	s.Close()
+	wg.Wait()
+
+	_, err := s.Dial(s.Addr())
+	require.Error(t, err)
+	require.Error(t, s.Outbind(s.Addr(), "smsc", "pwd"))
+	require.Zero(t, s.Bound())
+}
+
+func TestSubmitSM(t *testing.T) {
+	s := NewServer(Config{
+		SubmitStatus: func(req pdu.PDU) data.CommandStatusType {
+			if req.(*pdu.SubmitSM).DestAddr.Address() == "throttled" {
+				return data.ESME_RTHROTTLED
+			}
+			return data.ESME_ROK
+		},
+		DeliveryReceipt:      true,
+		DeliveryReceiptDelay: 10 * time.Millisecond,
+		DeliveryReceiptStat: func(*pdu.SubmitSM) string {
+			return "UNDELIV"
+		},
+	})
+	defer s.Close()
+
+	c, _ := bind(t, s, s.Dial)
+
+	req := pdu.NewSubmitSM().(*pdu.SubmitSM)
+	_ = req.DestAddr.SetAddress("throttled")
+	write(t, c, req)
+
+	resp := read(t, c).(*pdu.SubmitSMResp)
+	require.Equal(t, data.ESME_RTHROTTLED, resp.CommandStatus)
+
+	req = pdu.NewSubmitSM().(*pdu.SubmitSM)
+	req.RegisteredDelivery = data.SM_SMSC_RECEIPT_REQUESTED
+	write(t, c, req)
+
+	resp = read(t, c).(*pdu.SubmitSMResp)
+	require.Equal(t, data.ESME_ROK, resp.CommandStatus)
+	require.Equal(t, "1", resp.MessageID)
+
+	receipt := read(t, c).(*pdu.DeliverSM)
+	text, err := receipt.Message.GetMessage()
+	require.NoError(t, err)
+	require.Contains(t, text, "id:1 sub:001 dlvrd:000 ")
+	require.Contains(t, text, "stat:UNDELIV err:000")
+	require.Equal(t, []byte{data.SM_STATE_UNDELIVERABLE}, receipt.OptionalParameters[pdu.TagMessageStateOption].Data)
+}
+
+func TestStall(t *testing.T) {
+	s := NewServer(Config{})
+	defer s.Close()
+
+	c, _ := bind(t, s, s.Dial)
+
+	s.Stall()
+	write(t, c, pdu.NewEnquireLink())
+
+	require.NoError(t, c.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err := pdu.Parse(c)
+	require.Error(t, err)
+	require.Len(t, s.ReceivedOf(data.ENQUIRE_LINK), 1)
+
+	s.Resume()
+	require.Equal(t, data.ENQUIRE_LINK_RESP, read(t, c).GetHeader().CommandID)
+}
+
+func TestInjection(t *testing.T) {
+	s := NewServer(Config{})
+	defer s.Close()
+
+	c, _ := bind(t, s, s.Dial)
+
+	go func() {
+		_ = s.GenericNack(data.ESME_RINVCMDID)
+		_ = s.Unbind()
+	}()
+
+	nack := read(t, c)
+	require.True(t, nack.IsGNack())
+	require.Equal(t, data.ESME_RINVCMDID, nack.GetHeader().CommandStatus)
+
+	unbind, ok := read(t, c).(*pdu.Unbind)
+	require.True(t, ok)
+	write(t, c, unbind.GetResponse())
+
+	require.Eventually(t, func() bool {
+		return s.Bound() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	s.Reset()
+	require.Empty(t, s.Received())
+}
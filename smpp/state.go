@@ -29,6 +29,10 @@ const (
 
 	// UnbindClosing indicates Receiver got unbind request from SMSC and closed due to this request.
 	UnbindClosing
+
+	// UnresponsiveClosing indicates Transceiver is unbound and closed because SMSC did not respond
+	// to Settings.MaxResponseTimeouts consecutive requests.
+	UnresponsiveClosing
 )
 
 // String interface.
@@ -49,6 +53,9 @@ func (s *State) String() string {
 	case UnbindClosing:
 		return "UnbindClosing"
 
+	case UnresponsiveClosing:
+		return "UnresponsiveClosing"
+
 	default:
 		return ""
 	}
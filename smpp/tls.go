@@ -0,0 +1,164 @@
+package smpp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+)
+
+var (
+	// ErrSPKIPinMismatch indicates none of SMSC certificates matches pinned SPKI hashes.
+	ErrSPKIPinMismatch = fmt.Errorf("tls: SMSC certificate does not match any pinned public key")
+)
+
+// TLSConfig for TLSDialer.
+type TLSConfig struct {
+	// Config is base tls configuration, cloned for each connection.
+	// Nil uses zero tls.Config.
+	Config *tls.Config
+
+	// ServerName is used for SNI and verification of SMSC certificate.
+	// Empty uses Config.ServerName or host part of SMSC address.
+	ServerName string
+
+	// Certificates are presented to SMSC which requires client authentication (mutual TLS).
+	Certificates []tls.Certificate
+
+	// PinnedSPKI are SHA-256 hashes of SubjectPublicKeyInfo, see SPKIHash.
+	// If set, a certificate of chain verified for SMSC must match one of them.
+	// If Config.InsecureSkipVerify is set, SMSC leaf certificate must match.
+	PinnedSPKI [][]byte
+
+	// DialTimeout is timeout for establishing underlying connection, also applied to Dialer.
+	DialTimeout time.Duration
+
+	// HandshakeTimeout is timeout for tls handshake. Zero means no timeout.
+	HandshakeTimeout time.Duration
+
+	// Dialer dials underlying connection. Nil dials TCP.
+	Dialer Dialer
+}
+
+// SPKIHash returns SHA-256 hash of certificate SubjectPublicKeyInfo, used for pinning.
+func SPKIHash(cert *x509.Certificate) []byte {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return sum[:]
+}
+
+// TLSDialer returns dialer establishing tls connection to SMSC.
+func TLSDialer(config TLSConfig) Dialer {
+	return func(addr string) (net.Conn, error) {
+		raw, err := config.dial(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		conn := tls.Client(raw, config.clientConfig(addr))
+
+		if config.HandshakeTimeout > 0 {
+			if err = raw.SetDeadline(time.Now().Add(config.HandshakeTimeout)); err != nil {
+				_ = raw.Close()
+				return nil, err
+			}
+		}
+
+		if err = conn.Handshake(); err != nil {
+			_ = raw.Close()
+			return nil, err
+		}
+
+		if err = raw.SetDeadline(time.Time{}); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		return conn, nil
+	}
+}
+
+// dial underlying connection within DialTimeout.
+func (c *TLSConfig) dial(addr string) (net.Conn, error) {
+	if c.Dialer == nil {
+		d := &net.Dialer{Timeout: c.DialTimeout}
+		return d.Dial("tcp", addr)
+	}
+
+	if c.DialTimeout <= 0 {
+		return c.Dialer(addr)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.DialTimeout)
+	defer cancel()
+	return dial(ctx, c.Dialer, addr)
+}
+
+func (c *TLSConfig) clientConfig(addr string) (cfg *tls.Config) {
+	if c.Config != nil {
+		cfg = c.Config.Clone()
+	} else {
+		cfg = &tls.Config{}
+	}
+
+	if c.ServerName != "" {
+		cfg.ServerName = c.ServerName
+	} else if cfg.ServerName == "" {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			cfg.ServerName = host
+		} else {
+			cfg.ServerName = addr
+		}
+	}
+
+	if len(c.Certificates) > 0 {
+		cfg.Certificates = append(cfg.Certificates, c.Certificates...)
+	}
+
+	if len(c.PinnedSPKI) > 0 {
+		verify, insecure := cfg.VerifyConnection, cfg.InsecureSkipVerify
+		cfg.VerifyConnection = func(state tls.ConnectionState) error {
+			if verify != nil {
+				if err := verify(state); err != nil {
+					return err
+				}
+			}
+			return c.verifyPins(state, insecure)
+		}
+	}
+
+	return
+}
+
+// verifyPins matches certificates of verified chains, or only the leaf certificate
+// if verification is skipped. Other certificates presented by SMSC are not trusted.
+func (c *TLSConfig) verifyPins(state tls.ConnectionState, insecure bool) error {
+	if insecure {
+		if len(state.PeerCertificates) > 0 && c.pinned(state.PeerCertificates[0]) {
+			return nil
+		}
+		return ErrSPKIPinMismatch
+	}
+
+	for _, chain := range state.VerifiedChains {
+		for _, cert := range chain {
+			if c.pinned(cert) {
+				return nil
+			}
+		}
+	}
+	return ErrSPKIPinMismatch
+}
+
+func (c *TLSConfig) pinned(cert *x509.Certificate) bool {
+	hash := SPKIHash(cert)
+	for _, pin := range c.PinnedSPKI {
+		if bytes.Equal(hash, pin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package smpp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCertificate(t *testing.T, name string) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, cert
+}
+
+// newTLSProxy terminates tls and forwards traffic to simulated SMSC.
+func newTLSProxy(t *testing.T, smsc *smsctest.Server, config *tls.Config) string {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer func() {
+					_ = conn.Close()
+				}()
+
+				if err := conn.(*tls.Conn).Handshake(); err != nil {
+					return
+				}
+
+				upstream, err := smsc.Dial("")
+				if err != nil {
+					return
+				}
+				defer func() {
+					_ = upstream.Close()
+				}()
+
+				go func() {
+					_, _ = io.Copy(upstream, conn)
+					_ = upstream.Close()
+				}()
+				_, _ = io.Copy(conn, upstream)
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestTLSDialer(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	serverCert, serverX509 := newTestCertificate(t, "smsc.test")
+	clientCert, clientX509 := newTestCertificate(t, "esme.test")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverX509)
+
+	clients := x509.NewCertPool()
+	clients.AddCert(clientX509)
+
+	serverNames := make(chan string, 10)
+	addr := newTLSProxy(t, smsc, &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clients,
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverNames <- hello.ServerName
+			return nil, nil
+		},
+	})
+
+	bind := func(config TLSConfig) error {
+		conn, err := TRXConnector(TLSDialer(config), Auth{SMSC: addr}).Connect()
+		if err == nil {
+			require.Equal(t, smsctest.DefaultSystemID, conn.systemID)
+			_ = conn.Close()
+		}
+		return err
+	}
+
+	t.Run("mutual TLS with SNI", func(t *testing.T) {
+		require.NoError(t, bind(TLSConfig{
+			Config:           &tls.Config{RootCAs: roots},
+			ServerName:       "smsc.test",
+			Certificates:     []tls.Certificate{clientCert},
+			HandshakeTimeout: time.Second,
+		}))
+		require.Equal(t, "smsc.test", <-serverNames)
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		require.Error(t, bind(TLSConfig{
+			Config:     &tls.Config{RootCAs: roots},
+			ServerName: "smsc.test",
+		}))
+		<-serverNames
+	})
+
+	t.Run("pinned SPKI", func(t *testing.T) {
+		require.NoError(t, bind(TLSConfig{
+			Config:       &tls.Config{InsecureSkipVerify: true},
+			Certificates: []tls.Certificate{clientCert},
+			PinnedSPKI:   [][]byte{SPKIHash(clientX509), SPKIHash(serverX509)},
+		}))
+		<-serverNames
+
+		err := bind(TLSConfig{
+			Config:       &tls.Config{RootCAs: roots},
+			ServerName:   "smsc.test",
+			Certificates: []tls.Certificate{clientCert},
+			PinnedSPKI:   [][]byte{SPKIHash(clientX509)},
+		})
+		require.ErrorIs(t, err, ErrSPKIPinMismatch)
+		<-serverNames
+	})
+
+	t.Run("pinned SPKI of unverified certificate", func(t *testing.T) {
+		// SMSC presents extra certificate which is not part of verified chain
+		presented := serverCert
+		presented.Certificate = [][]byte{serverCert.Certificate[0], clientX509.Raw}
+		addr := newTLSProxy(t, smsc, &tls.Config{Certificates: []tls.Certificate{presented}})
+
+		dial := func(config TLSConfig) error {
+			conn, err := TRXConnector(TLSDialer(config), Auth{SMSC: addr}).Connect()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return err
+		}
+
+		require.NoError(t, dial(TLSConfig{
+			Config:     &tls.Config{RootCAs: roots},
+			ServerName: "smsc.test",
+			PinnedSPKI: [][]byte{SPKIHash(serverX509)},
+		}))
+
+		err := dial(TLSConfig{
+			Config:     &tls.Config{RootCAs: roots},
+			ServerName: "smsc.test",
+			PinnedSPKI: [][]byte{SPKIHash(clientX509)},
+		})
+		require.ErrorIs(t, err, ErrSPKIPinMismatch)
+
+		err = dial(TLSConfig{
+			Config:     &tls.Config{InsecureSkipVerify: true},
+			PinnedSPKI: [][]byte{SPKIHash(clientX509)},
+		})
+		require.ErrorIs(t, err, ErrSPKIPinMismatch)
+	})
+
+	t.Run("dial timeout of Dialer", func(t *testing.T) {
+		start := time.Now()
+		_, err := TLSDialer(TLSConfig{
+			DialTimeout: 50 * time.Millisecond,
+			Dialer: func(string) (net.Conn, error) {
+				time.Sleep(time.Second)
+				return nil, fmt.Errorf("fake error")
+			},
+		})("smsc.test:2775")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("handshake timeout", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() {
+			_ = l.Close()
+		}()
+
+		// accept but never handshake
+		go func() {
+			conn, err := l.Accept()
+			if err == nil {
+				defer func() {
+					_ = conn.Close()
+				}()
+				time.Sleep(time.Second)
+			}
+		}()
+
+		start := time.Now()
+		_, err = TLSDialer(TLSConfig{HandshakeTimeout: 50 * time.Millisecond})(l.Addr().String())
+		require.Error(t, err)
+		require.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+}
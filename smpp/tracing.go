@@ -0,0 +1,325 @@
+package smpp
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+// Span names and attribute keys used by Session tracing.
+const (
+	SpanSubmitSM        = "smpp.submit_sm"
+	SpanDeliveryReceipt = "smpp.delivery_receipt"
+
+	AttrSessionID      = "smpp.session_id"
+	AttrSystemID       = "smpp.system_id"
+	AttrSequenceNumber = "smpp.sequence_number"
+	AttrMessageID      = "smpp.message_id"
+	AttrCommandStatus  = "smpp.command_status"
+	AttrMessageState   = "smpp.message_state"
+)
+
+// DefaultTraceReceiptLinks is number of recent message_id kept to link delivery receipts
+// with their submit_sm spans.
+const DefaultTraceReceiptLinks = 100000
+
+// Tracer starts spans. It mirrors the subset of OpenTelemetry tracer used by Session,
+// so adapting trace.Tracer takes a few lines while this package stays free of the dependency.
+//
+// Implementations must be safe for concurrent use.
+type Tracer interface {
+	// Start span as child of the span carried by ctx, if any, linked to given span contexts.
+	Start(ctx context.Context, name string, links ...SpanContext) (context.Context, Span)
+}
+
+// Span is a traced operation.
+type Span interface {
+	// SpanContext identifies the span.
+	SpanContext() SpanContext
+
+	// SetAttributes attaches attributes to the span.
+	SetAttributes(attrs ...Attribute)
+
+	// End the span. Non-nil err marks it failed.
+	End(err error)
+}
+
+// SpanContext identifies a span within a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid returns true if both trace and span ID are set.
+func (c SpanContext) IsValid() bool {
+	return c.TraceID != [16]byte{} && c.SpanID != [8]byte{}
+}
+
+// String interface.
+func (c SpanContext) String() string {
+	return hex.EncodeToString(c.TraceID[:]) + "-" + hex.EncodeToString(c.SpanID[:])
+}
+
+// Attribute is key-value pair attached to span.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// CommandStatusError is error of span whose response carries non-zero command status.
+type CommandStatusError struct {
+	Status data.CommandStatusType
+}
+
+// Error interface.
+func (e *CommandStatusError) Error() string {
+	return fmt.Sprintf("response with command status: %s", e.Status)
+}
+
+// SpanData is span recorded by MemoryTracer.
+type SpanData struct {
+	Name        string
+	SpanContext SpanContext
+	Parent      SpanContext
+	Links       []SpanContext
+	Attributes  map[string]interface{}
+	Start       time.Time
+	End         time.Time
+	Err         error
+}
+
+// MemoryTracer keeps ended spans in memory, for tests and debugging.
+type MemoryTracer struct {
+	spanID uint64
+
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewMemoryTracer creates MemoryTracer.
+func NewMemoryTracer() *MemoryTracer {
+	return &MemoryTracer{}
+}
+
+type memorySpanKey struct{}
+
+// Start implements Tracer.
+func (t *MemoryTracer) Start(ctx context.Context, name string, links ...SpanContext) (context.Context, Span) {
+	s := &memorySpan{
+		tracer: t,
+		data: SpanData{
+			Name:       name,
+			Links:      append([]SpanContext(nil), links...),
+			Attributes: make(map[string]interface{}),
+			Start:      time.Now(),
+		},
+	}
+
+	if parent, ok := ctx.Value(memorySpanKey{}).(SpanContext); ok {
+		s.data.Parent = parent
+		s.data.SpanContext.TraceID = parent.TraceID
+	} else {
+		_, _ = rand.Read(s.data.SpanContext.TraceID[:])
+	}
+
+	id := atomic.AddUint64(&t.spanID, 1)
+	for i := range s.data.SpanContext.SpanID {
+		s.data.SpanContext.SpanID[7-i] = byte(id >> (8 * i))
+	}
+
+	return context.WithValue(ctx, memorySpanKey{}, s.data.SpanContext), s
+}
+
+// Spans returns ended spans in the order they ended.
+func (t *MemoryTracer) Spans() []SpanData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SpanData(nil), t.spans...)
+}
+
+// Reset discards recorded spans.
+func (t *MemoryTracer) Reset() {
+	t.mu.Lock()
+	t.spans = nil
+	t.mu.Unlock()
+}
+
+type memorySpan struct {
+	tracer *MemoryTracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *memorySpan) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+func (s *memorySpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	for _, attr := range attrs {
+		s.data.Attributes[attr.Key] = attr.Value
+	}
+	s.mu.Unlock()
+}
+
+func (s *memorySpan) End(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End, s.data.Err = time.Now(), err
+
+	data := s.data
+	data.Attributes = make(map[string]interface{}, len(s.data.Attributes))
+	for k, v := range s.data.Attributes {
+		data.Attributes[k] = v
+	}
+	s.mu.Unlock()
+
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, data)
+	s.tracer.mu.Unlock()
+}
+
+// receiptLinks maps message_id of recently submitted messages to their spans, evicting the oldest.
+// It is shared by sessions of a Manager since receipt might arrive over another bind.
+type receiptLinks struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	spans    map[string]*list.Element
+}
+
+type receiptLink struct {
+	messageID string
+	span      SpanContext
+}
+
+func newReceiptLinks(capacity int) *receiptLinks {
+	return &receiptLinks{
+		capacity: capacity,
+		order:    list.New(),
+		spans:    make(map[string]*list.Element),
+	}
+}
+
+func (l *receiptLinks) put(messageID string, span SpanContext) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.spans[messageID]; ok {
+		e.Value.(*receiptLink).span = span
+		l.order.MoveToBack(e)
+		return
+	}
+
+	l.spans[messageID] = l.order.PushBack(&receiptLink{messageID: messageID, span: span})
+	for l.order.Len() > l.capacity {
+		oldest := l.order.Front()
+		l.order.Remove(oldest)
+		delete(l.spans, oldest.Value.(*receiptLink).messageID)
+	}
+}
+
+// get returns span of message_id. Link is forgotten once final receipt arrives.
+func (l *receiptLinks) get(messageID string, final bool) (span SpanContext, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.spans[messageID]
+	if !ok {
+		return
+	}
+
+	if final {
+		l.order.Remove(e)
+		delete(l.spans, messageID)
+	}
+	return e.Value.(*receiptLink).span, true
+}
+
+// traceRequest starts span of request if it is traced.
+func (t *transceivable) traceRequest(ctx context.Context, p pdu.PDU) Span {
+	if t.settings.Tracer == nil {
+		return nil
+	}
+	if _, ok := p.(*pdu.SubmitSM); !ok {
+		return nil
+	}
+
+	_, span := t.settings.Tracer.Start(ctx, SpanSubmitSM)
+	span.SetAttributes(
+		Attribute{Key: AttrSessionID, Value: t.settings.sessionID},
+		Attribute{Key: AttrSystemID, Value: t.conn.systemID},
+		Attribute{Key: AttrSequenceNumber, Value: p.GetSequenceNumber()},
+	)
+	return span
+}
+
+// endRequestSpan ends span of request with its response or error.
+func (t *transceivable) endRequestSpan(r *pendingRequest, resp pdu.PDU, err error) {
+	if r.span == nil {
+		return
+	}
+
+	if resp != nil {
+		status := resp.GetHeader().CommandStatus
+		r.span.SetAttributes(Attribute{Key: AttrCommandStatus, Value: status.String()})
+
+		if status != data.ESME_ROK {
+			err = &CommandStatusError{Status: status}
+		} else if v, ok := resp.(*pdu.SubmitSMResp); ok && v.MessageID != "" {
+			r.span.SetAttributes(Attribute{Key: AttrMessageID, Value: v.MessageID})
+			if t.settings.receipts != nil {
+				t.settings.receipts.put(v.MessageID, r.span.SpanContext())
+			}
+		}
+	}
+
+	r.span.End(err)
+}
+
+// traceReceipt starts span of delivery receipt, linked to span of its submit_sm if known.
+// Returns nil if p is not a traced delivery receipt.
+func (t *transceivable) traceReceipt(p pdu.PDU) Span {
+	d, ok := p.(*pdu.DeliverSM)
+	if !ok || t.settings.Tracer == nil || !d.IsDeliveryReceipt() {
+		return nil
+	}
+
+	attrs := []Attribute{
+		{Key: AttrSessionID, Value: t.settings.sessionID},
+		{Key: AttrSystemID, Value: t.conn.systemID},
+		{Key: AttrSequenceNumber, Value: p.GetSequenceNumber()},
+	}
+
+	var links []SpanContext
+	if receipt, err := pdu.ParseDeliveryReceipt(d); err == nil && receipt.MessageID != "" {
+		attrs = append(attrs,
+			Attribute{Key: AttrMessageID, Value: receipt.MessageID},
+			Attribute{Key: AttrMessageState, Value: receipt.State.String()},
+		)
+
+		if t.settings.receipts != nil {
+			if span, ok := t.settings.receipts.get(receipt.MessageID, receipt.State.IsFinal()); ok {
+				links = append(links, span)
+			}
+		}
+	}
+
+	_, span := t.settings.Tracer.Start(context.Background(), SpanDeliveryReceipt, links...)
+	span.SetAttributes(attrs...)
+	return span
+}
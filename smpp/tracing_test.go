@@ -0,0 +1,125 @@
+package smpp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTracer(t *testing.T) {
+	tracer := NewMemoryTracer()
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child", parent.SpanContext())
+	child.SetAttributes(Attribute{Key: "key", Value: 1})
+	child.End(errors.New("failed"))
+	child.End(nil)
+	parent.End(nil)
+
+	spans := tracer.Spans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "child", spans[0].Name)
+	require.True(t, spans[0].SpanContext.IsValid())
+	require.Equal(t, parent.SpanContext(), spans[0].Parent)
+	require.Equal(t, parent.SpanContext().TraceID, spans[0].SpanContext.TraceID)
+	require.NotEqual(t, parent.SpanContext().SpanID, spans[0].SpanContext.SpanID)
+	require.Equal(t, []SpanContext{parent.SpanContext()}, spans[0].Links)
+	require.Equal(t, map[string]interface{}{"key": 1}, spans[0].Attributes)
+	require.EqualError(t, spans[0].Err, "failed")
+	require.False(t, spans[1].Parent.IsValid())
+
+	tracer.Reset()
+	require.Empty(t, tracer.Spans())
+}
+
+func TestReceiptLinks(t *testing.T) {
+	links := newReceiptLinks(2)
+	links.put("1", SpanContext{SpanID: [8]byte{1}})
+	links.put("2", SpanContext{SpanID: [8]byte{2}})
+	links.put("3", SpanContext{SpanID: [8]byte{3}})
+
+	_, ok := links.get("1", false)
+	require.False(t, ok)
+
+	span, ok := links.get("2", false)
+	require.True(t, ok)
+	require.Equal(t, [8]byte{2}, span.SpanID)
+
+	_, ok = links.get("2", true)
+	require.True(t, ok)
+	_, ok = links.get("2", false)
+	require.False(t, ok)
+}
+
+func TestSessionTracing(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{
+		DeliveryReceipt: true,
+		SubmitStatus: func(req pdu.PDU) data.CommandStatusType {
+			if req.(*pdu.SubmitSM).SourceAddr.Address() == "rejected" {
+				return data.ESME_RTHROTTLED
+			}
+			return data.ESME_ROK
+		},
+	})
+	defer smsc.Close()
+
+	tracer := NewMemoryTracer()
+	session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+		ReadTimeout: time.Second,
+		Tracer:      tracer,
+		OnPDU: func(p pdu.PDU, _ bool) {
+			// receipt span covers handling
+			time.Sleep(10 * time.Millisecond)
+		},
+	}, 0)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+
+	ctx, parent := tracer.Start(context.Background(), "customer message")
+	resp, err := session.Transceiver().SubmitResp(ctx, newSubmitSM("esme"))
+	require.NoError(t, err)
+	messageID := resp.(*pdu.SubmitSMResp).MessageID
+
+	require.Eventually(t, func() bool {
+		return len(tracer.Spans()) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	spans := tracer.Spans()
+	submit, receipt := spans[0], spans[1]
+	require.Equal(t, SpanSubmitSM, submit.Name)
+	require.Equal(t, parent.SpanContext(), submit.Parent)
+	require.NoError(t, submit.Err)
+	require.Equal(t, map[string]interface{}{
+		AttrSessionID:      session.ID,
+		AttrSystemID:       smsctest.DefaultSystemID,
+		AttrSequenceNumber: resp.GetSequenceNumber(),
+		AttrCommandStatus:  "ESME_ROK",
+		AttrMessageID:      messageID,
+	}, submit.Attributes)
+
+	require.Equal(t, SpanDeliveryReceipt, receipt.Name)
+	require.Equal(t, []SpanContext{submit.SpanContext}, receipt.Links)
+	require.Equal(t, messageID, receipt.Attributes[AttrMessageID])
+	require.Equal(t, "DELIVRD", receipt.Attributes[AttrMessageState])
+	require.GreaterOrEqual(t, receipt.End.Sub(receipt.Start), 10*time.Millisecond)
+
+	tracer.Reset()
+	_, err = session.Transceiver().SubmitResp(context.Background(), newSubmitSM("rejected"))
+	require.NoError(t, err)
+
+	spans = tracer.Spans()
+	require.Len(t, spans, 1)
+	var statusErr *CommandStatusError
+	require.ErrorAs(t, spans[0].Err, &statusErr)
+	require.Equal(t, data.ESME_RTHROTTLED, statusErr.Status)
+	require.Nil(t, spans[0].Attributes[AttrMessageID])
+}
@@ -2,26 +2,75 @@ package smpp
 
 import (
 	"context"
+	"fmt"
 	"github.com/go-errors/errors"
+	"github.com/sujit-baniya/protocol/smpp/data"
 	"github.com/sujit-baniya/protocol/smpp/pdu"
 	"golang.org/x/time/rate"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+var (
+	// ErrResponseTimeout indicates response is not received within Settings.ResponseTimeout.
+	ErrResponseTimeout = fmt.Errorf("timeout waiting for response from SMSC")
+)
+
+// DefaultSubmitTracking is how long request submitted via Submit waits for its response
+// if no response timeout applies. It is forgotten afterwards, without notifying OnResponseTimeout,
+// and its late response is passed to OnUnmatchedResponse.
+const DefaultSubmitTracking = 5 * time.Minute
+
+// pendingRequest is request waiting for its response.
+type pendingRequest struct {
+	req pdu.PDU
+
+	// id and sequence of req are captured at registering, req belongs to transmitter afterwards.
+	id       data.CommandIDType
+	sequence int32
+
+	// sent is zero until request is written, response deadline starts then.
+	sent     time.Time
+	timeout  time.Duration
+	deadline time.Time
+
+	// forget request quietly once deadline passes, see DefaultSubmitTracking.
+	forget bool
+
+	// result is nil for request sent via Submit, its response goes to OnPDU.
+	result chan Result
+
+	// lost is called if transceiver is closed before response arrives.
+	lost func(pdu.PDU)
+
+	// span traces request until response arrives, nil if not traced.
+	span Span
+
+	// requeues counts resubmissions of throttled request, see AdaptiveRateSettings.Requeue.
+	requeues int
+}
+
 type transceivable struct {
 	settings Settings
 
 	conn        *Connection
 	in          *receivable
 	out         *transmittable
-	pending     map[int32]func(pdu.PDU)
+	pending     map[int32]*pendingRequest
+	window      chan struct{}
 	rateLimiter *rate.Limiter
+	adaptive    *adaptiveRate
 	ctx         context.Context
 	ctxCancel   context.CancelFunc
 	aliveState  int32
+	draining    int32 // shutting down, no new request accepted
+	timeouts    int32 // consecutive response timeouts
+	idle        chan struct{}
 	mutex       *sync.Mutex
+	logger      Logger
+	metrics     Metrics
 }
 
 func newTransceivable(conn *Connection, settings Settings) *transceivable {
@@ -30,16 +79,30 @@ func newTransceivable(conn *Connection, settings Settings) *transceivable {
 		settings:    settings,
 		conn:        conn,
 		rateLimiter: settings.RateLimiter,
+		adaptive:    settings.adaptive,
 		ctx:         ctx,
 		ctxCancel:   cancel,
-		pending:     make(map[int32]func(pdu.PDU)),
+		pending:     make(map[int32]*pendingRequest),
+		idle:        make(chan struct{}, 1),
 		mutex:       &sync.Mutex{},
+		logger:      withFields(settings.Logger, "system_id", conn.systemID),
+		metrics:     metricsOf(&settings),
+	}
+	if t.adaptive == nil && settings.AdaptiveRate != nil {
+		t.adaptive = newAdaptiveRate(*settings.AdaptiveRate)
+	}
+	if settings.WindowSize > 0 {
+		t.window = make(chan struct{}, settings.WindowSize)
 	}
 
 	t.out = newTransmittable(conn, Settings{
 		WriteTimeout: settings.WriteTimeout,
+		WriteBatch:   settings.WriteBatch,
+		Metrics:      t.metrics,
+		sessionID:    settings.sessionID,
+		written:      t.written,
 
-		OnSubmitError: settings.OnSubmitError,
+		OnSubmitError: t.onSubmitError,
 
 		OnClosed: func(state State) {
 			defer cancel()
@@ -47,12 +110,13 @@ func newTransceivable(conn *Connection, settings Settings) *transceivable {
 			case ExplicitClosing:
 				return
 
-			case ConnectionIssue:
+			case ConnectionIssue, UnresponsiveClosing:
 				// also close input
 				_ = t.in.close(ExplicitClosing)
 
-				if t.settings.OnClosed != nil {
-					t.settings.OnClosed(ConnectionIssue)
+				// shutdown notifies closing on its own
+				if t.settings.OnClosed != nil && !t.isDraining() {
+					t.settings.OnClosed(state)
 				}
 			}
 		},
@@ -60,10 +124,12 @@ func newTransceivable(conn *Connection, settings Settings) *transceivable {
 
 	t.in = newReceivable(conn, Settings{
 		ReadTimeout: settings.ReadTimeout,
+		Metrics:     t.metrics,
+		sessionID:   settings.sessionID,
 
 		OnPDU: t.onPDU(settings.OnPDU),
 
-		OnReceivingError: settings.OnReceivingError,
+		OnReceivingError: t.onReceivingError,
 
 		OnClosed: func(state State) {
 			defer cancel()
@@ -72,10 +138,15 @@ func newTransceivable(conn *Connection, settings Settings) *transceivable {
 				return
 
 			case InvalidStreaming, UnbindClosing:
+				if state == UnbindClosing {
+					t.out.skipUnbind()
+				}
+
 				// also close output
 				_ = t.out.close(ExplicitClosing)
 
-				if t.settings.OnClosed != nil {
+				// shutdown notifies closing on its own
+				if t.settings.OnClosed != nil && !t.isDraining() {
 					t.settings.OnClosed(state)
 				}
 			}
@@ -94,6 +165,7 @@ func newTransceivable(conn *Connection, settings Settings) *transceivable {
 func (t *transceivable) start() {
 	t.out.start()
 	t.in.start()
+	go t.loopPending()
 	if t.settings.EnquireLink > 0 {
 		go t.loopWithEnquireLink()
 	}
@@ -106,12 +178,21 @@ func (t *transceivable) loopWithEnquireLink() {
 		ctxSubmit, cancel := context.WithTimeout(t.ctx, time.Minute*5)
 		defer cancel()
 
+		start := time.Now()
 		_, err := t.SubmitResp(ctxSubmit, eqp)
 		if err != nil {
-			t.settings.OnSubmitError(eqp, err)
+			// transceiver is closing, not an enquire link failure
+			if err == ErrConnectionClosing || t.ctx.Err() != nil {
+				return
+			}
+
+			t.onSubmitError(eqp, err)
 			_ = t.Close()
 			return
 		}
+		rtt := time.Since(start)
+		t.metrics.EnquireLinkRTT(t.settings.sessionID, rtt)
+		t.logger.Debug("enquire_link responded", "rtt", rtt)
 	}
 	for {
 		select {
@@ -129,6 +210,19 @@ func (t *transceivable) SystemID() string {
 	return t.conn.systemID
 }
 
+// InterfaceVersion returns interface version negotiated with SMSC while binding.
+func (t *transceivable) InterfaceVersion() byte {
+	return t.conn.interfaceVersion
+}
+
+// supports returns ErrUnsupportedInterfaceVersion if PDU is not supported by negotiated interface version.
+func (t *transceivable) supports(p pdu.PDU) error {
+	if requiredInterfaceVersion(p) > t.conn.interfaceVersion {
+		return ErrUnsupportedInterfaceVersion
+	}
+	return nil
+}
+
 // Close transceiver and stop underlying daemons.
 func (t *transceivable) Close() (err error) {
 	defer t.ctxCancel()
@@ -149,73 +243,515 @@ func (t *transceivable) Close() (err error) {
 }
 func (t *transceivable) onPDU(cl PDUCallback) PDUCallback {
 	return func(p pdu.PDU, responded bool) {
-		t.mutex.Lock()
-		defer t.mutex.Unlock()
-		if callback, ok := t.pending[p.GetSequenceNumber()]; ok {
-			go callback(p)
-		} else {
-			if cl == nil {
-				if p.CanResponse() {
-					go func() {
-						_ = t.Submit(p.GetResponse())
-					}()
+		t.logPDU("received PDU", p)
+
+		if !isRequest(p) {
+			h := p.GetHeader()
+			t.metrics.ResponseStatus(t.settings.sessionID, h.CommandID, h.CommandStatus)
+
+			if t.throttle(p) {
+				atomic.StoreInt32(&t.timeouts, 0)
+				return
+			}
+
+			r, ok := t.complete(p.GetSequenceNumber(), p, nil)
+			if !ok {
+				if t.settings.OnUnmatchedResponse != nil {
+					go t.settings.OnUnmatchedResponse(p)
 				}
-			} else {
-				go cl(p, responded)
+				return
+			}
+
+			atomic.StoreInt32(&t.timeouts, 0)
+			if r.result != nil {
+				return
 			}
+		}
 
+		span := t.traceReceipt(p)
+		if cl == nil {
+			if p.CanResponse() {
+				go func() {
+					_ = t.Submit(p.GetResponse())
+				}()
+			}
+			if span != nil {
+				span.End(nil)
+			}
+		} else {
+			go func() {
+				cl(p, responded)
+				if span != nil {
+					span.End(nil)
+				}
+			}()
 		}
 	}
 }
 
-// Submit a PDU.
+// Submit a PDU. Request PDU is assigned with next sequence number of the connection.
+//
+// Response to submitted request is handled by OnPDU.
 func (t *transceivable) Submit(p pdu.PDU) error {
-	err := t.rateLimit(t.ctx)
+	return t.SubmitContext(context.Background(), p)
+}
+
+// SubmitContext is like Submit, but waiting for rate limiter and
+// enqueueing are aborted once ctx is done. Request still queued once ctx is done
+// is not written, but reported to OnSubmitError.
+func (t *transceivable) SubmitContext(ctx context.Context, p pdu.PDU) error {
+	return t.submitRequest(ctx, p, nil)
+}
+
+// submitRequest is like SubmitContext. If transceiver is closed before response of
+// submitted request arrives, the request is handed over to lost instead.
+func (t *transceivable) submitRequest(ctx context.Context, p pdu.PDU, lost func(pdu.PDU)) error {
+	if isRequest(p) && t.isDraining() {
+		return ErrConnectionClosing
+	}
+	if err := t.supports(p); err != nil {
+		return err
+	}
+	t.conn.AssignSequenceNumber(p)
+
+	if !isRequest(p) || !p.CanResponse() {
+		return t.submit(ctx, p)
+	}
+
+	sequence := p.GetSequenceNumber()
+	if err := t.register(ctx, &pendingRequest{req: p, lost: lost}); err != nil {
+		return err
+	}
+
+	err := t.submit(ctx, p)
+	if err != nil {
+		if _, ok := t.complete(sequence, nil, err); !ok && lost != nil {
+			// already handed over to lost by closing transceiver
+			return nil
+		}
+	}
+	return err
+}
+
+func (t *transceivable) submit(ctx context.Context, p pdu.PDU) error {
+	err := t.rateLimit(ctx, p)
 	if err != nil {
 		return err
 	}
-	return t.out.Submit(p)
+
+	// PDU belongs to transmitter once enqueued
+	t.logPDU("submitting PDU", p)
+	return t.out.SubmitContext(ctx, p)
+}
+
+// logPDU logs PDU at debug level, with hex dump if Settings.LogHexDump is set.
+func (t *transceivable) logPDU(msg string, p pdu.PDU) {
+	if _, ok := t.logger.(nopLogger); !ok {
+		t.logger.Debug(msg, pduFields(p, t.settings.LogHexDump)...)
+	}
+}
+
+func (t *transceivable) onSubmitError(p pdu.PDU, err error) {
+	// request is dropped by transmitter before written, see transmittable.SubmitContext
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		if r, ok := t.complete(p.GetSequenceNumber(), nil, err); ok && r.lost != nil {
+			r.lost(r.req)
+			return
+		}
+	}
+
+	t.logger.Warn("failed to submit PDU", append(pduFields(p, false), "error", err)...)
+	if t.settings.OnSubmitError != nil {
+		t.settings.OnSubmitError(p, err)
+	}
+}
+
+func (t *transceivable) onReceivingError(err error) {
+	t.logger.Warn("failed to receive PDU", "error", err)
+	if t.settings.OnReceivingError != nil {
+		t.settings.OnReceivingError(err)
+	}
 }
 
 // SubmitResp a PDU and response PDU.
 func (t *transceivable) SubmitResp(ctx context.Context, p pdu.PDU) (resp pdu.PDU, err error) {
+	if t.isDraining() {
+		return nil, ErrConnectionClosing
+	}
+	return t.submitResp(ctx, p)
+}
+
+func (t *transceivable) submitResp(ctx context.Context, p pdu.PDU) (resp pdu.PDU, err error) {
 	if !p.CanResponse() {
 		return nil, errors.New("Not response PDU")
 	}
+
+	if err = t.supports(p); err != nil {
+		return
+	}
+
+	if err = t.acquire(ctx); err != nil {
+		return
+	}
+
+	result := t.send(ctx, p)
+
+	select {
+	case r := <-result:
+		return r.Response, r.Err
+
+	case <-ctx.Done():
+		t.complete(p.GetSequenceNumber(), nil, ctx.Err())
+
+		// response might win the race
+		r := <-result
+		return r.Response, r.Err
+	}
+}
+
+// SubmitAsync a PDU. Returned channel receives exactly one Result when response arrives,
+// Settings.ResponseTimeout passes or transceiver is closed.
+//
+// If Settings.WindowSize is reached, SubmitAsync blocks until a response frees the window.
+func (t *transceivable) SubmitAsync(p pdu.PDU) <-chan Result {
+	if !p.CanResponse() {
+		result := make(chan Result, 1)
+		result <- Result{Request: p, Err: errors.New("Not response PDU")}
+		return result
+	}
+
+	err := t.supports(p)
+	if t.isDraining() {
+		err = ErrConnectionClosing
+	}
+	if err == nil {
+		err = t.acquire(t.ctx)
+	}
+
+	if err != nil {
+		result := make(chan Result, 1)
+		result <- Result{Request: p, Err: err}
+		return result
+	}
+
+	return t.send(t.ctx, p)
+}
+
+// send registers pending request and submits it. Window must be acquired.
+func (t *transceivable) send(ctx context.Context, p pdu.PDU) (result chan Result) {
+	t.conn.AssignSequenceNumber(p)
 	sequence := p.GetSequenceNumber()
-	returns := make(chan pdu.PDU, 1)
 
-	func() {
-		t.mutex.Lock()
-		defer t.mutex.Unlock()
-		t.pending[sequence] = func(resp pdu.PDU) { returns <- resp }
-	}()
+	result = make(chan Result, 1)
+	if err := t.register(ctx, &pendingRequest{req: p, result: result}); err != nil {
+		t.release()
+		result <- Result{Request: p, Err: err}
+		return
+	}
 
-	defer func() {
-		t.mutex.Lock()
-		defer t.mutex.Unlock()
+	if err := t.submit(ctx, p); err != nil {
+		t.complete(sequence, nil, err)
+	}
+	return
+}
+
+// register request waiting for response. Its response deadline starts once it is written.
+func (t *transceivable) register(ctx context.Context, r *pendingRequest) error {
+	r.id, r.sequence = r.req.GetHeader().CommandID, r.req.GetSequenceNumber()
+	r.sent, r.deadline = time.Time{}, time.Time{}
+	r.timeout = t.responseTimeout(r.id)
+	if r.forget = r.timeout == 0 && r.result == nil; r.forget {
+		r.timeout = DefaultSubmitTracking
+	}
+	r.span = t.traceRequest(ctx, r.req)
+
+	t.mutex.Lock()
+	if t.ctx.Err() != nil {
+		t.mutex.Unlock()
+		t.endRequestSpan(r, nil, ErrConnectionClosing)
+		return ErrConnectionClosing
+	}
+	t.pending[r.sequence] = r
+	t.mutex.Unlock()
+	return nil
+}
+
+// written starts response deadline of pending request.
+func (t *transceivable) written(sequence int32) {
+	now := time.Now()
+
+	t.mutex.Lock()
+	if r, ok := t.pending[sequence]; ok && r.sent.IsZero() {
+		r.sent = now
+		if r.timeout > 0 {
+			r.deadline = now.Add(r.timeout)
+		}
+	}
+	t.mutex.Unlock()
+}
+
+// responseTimeout returns response timeout for request with given command_id.
+func (t *transceivable) responseTimeout(id data.CommandIDType) time.Duration {
+	if timeout, ok := t.settings.ResponseTimeouts[id]; ok {
+		return timeout
+	}
+	return t.settings.ResponseTimeout
+}
+
+// complete pending request with its response or error.
+// Returns false if there is no pending request with given sequence number.
+func (t *transceivable) complete(sequence int32, resp pdu.PDU, err error) (r *pendingRequest, ok bool) {
+	t.mutex.Lock()
+	r, ok = t.pending[sequence]
+	if ok {
 		delete(t.pending, sequence)
-	}()
+		if len(t.pending) == 0 {
+			signal(t.idle)
+		}
+	}
+	t.mutex.Unlock()
+
+	// response might arrive before transmitter notifies request is written
+	if ok && resp != nil && !r.sent.IsZero() {
+		t.metrics.ResponseLatency(t.settings.sessionID, r.id, time.Since(r.sent))
+	}
+	if ok {
+		t.endRequestSpan(r, resp, err)
+	}
+
+	if ok && r.result != nil {
+		r.result <- Result{Request: r.req, Response: resp, Err: err}
+		t.release()
+	}
+	return
+}
+
+func (t *transceivable) isDraining() bool {
+	return atomic.LoadInt32(&t.draining) == 1
+}
+
+// shutdown stops accepting new requests, waits for pending responses until ctx is done,
+// then exchanges unbind with SMSC and closes.
+//
+// Returns requests left without response, in submission order.
+func (t *transceivable) shutdown(ctx context.Context) (unacknowledged []pdu.PDU, err error) {
+	atomic.StoreInt32(&t.draining, 1)
+
+	if err = t.waitPending(ctx); err == nil {
+		err = t.unbind(ctx)
+	}
+
+	// requests sent via Submit are reported here only, others still get ErrConnectionClosing
+	t.mutex.Lock()
+	for sequence, r := range t.pending {
+		unacknowledged = append(unacknowledged, r.req)
+		if r.result == nil {
+			delete(t.pending, sequence)
+		}
+	}
+	t.mutex.Unlock()
+
+	sort.Slice(unacknowledged, func(i, j int) bool {
+		return unacknowledged[i].GetSequenceNumber() < unacknowledged[j].GetSequenceNumber()
+	})
+
+	_ = t.Close()
+	return
+}
+
+// waitPending waits until no request is waiting for response.
+func (t *transceivable) waitPending(ctx context.Context) error {
+	for {
+		t.mutex.Lock()
+		n := len(t.pending)
+		t.mutex.Unlock()
+
+		if n == 0 {
+			return nil
+		}
 
-	err = t.Submit(p)
+		select {
+		case <-t.idle:
+		case <-t.ctx.Done():
+			return ErrConnectionClosing
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// unbind sends unbind and waits for unbind_resp.
+func (t *transceivable) unbind(ctx context.Context) error {
+	// transmitter must not send another unbind while closing
+	t.out.skipUnbind()
+
+	resp, err := t.submitResp(ctx, pdu.NewUnbind())
 	if err != nil {
-		return
+		return err
+	}
+
+	if status := resp.GetHeader().CommandStatus; status != data.ESME_ROK {
+		return fmt.Errorf("unbind is rejected with command status: [%d]", status)
+	}
+	return nil
+}
+
+// acquire a slot of window.
+func (t *transceivable) acquire(ctx context.Context) error {
+	if t.window == nil {
+		return nil
 	}
+
 	select {
+	case t.window <- struct{}{}:
+		t.metrics.WindowOccupancy(t.settings.sessionID, len(t.window))
+		return nil
+	case <-t.ctx.Done():
+		return ErrConnectionClosing
 	case <-ctx.Done():
-		err = ctx.Err()
-	case resp = <-returns:
+		return ctx.Err()
+	}
+}
+
+// release a slot of window.
+func (t *transceivable) release() {
+	if t.window != nil {
+		<-t.window
+		t.metrics.WindowOccupancy(t.settings.sessionID, len(t.window))
+	}
+}
+
+// loopPending expires stale pending requests, and fails all of them once transceiver is closed.
+func (t *transceivable) loopPending() {
+	timeout := t.minResponseTimeout()
+	if timeout == 0 || timeout > DefaultSubmitTracking {
+		timeout = DefaultSubmitTracking
+	}
+
+	interval := timeout / 10
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			t.expire(nil, ErrConnectionClosing)
+			return
+
+		case now := <-ticker.C:
+			t.expire(func(r *pendingRequest) bool {
+				return !r.deadline.IsZero() && now.After(r.deadline)
+			}, ErrResponseTimeout)
+		}
+	}
+}
+
+// expire pending requests matched by filter, or all of them if filter is nil.
+func (t *transceivable) expire(filter func(*pendingRequest) bool, err error) {
+	var expired []int32
+
+	t.mutex.Lock()
+	for sequence, r := range t.pending {
+		if filter == nil || filter(r) {
+			expired = append(expired, sequence)
+		}
+	}
+	t.mutex.Unlock()
+
+	// keep submission order for lost requests
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i] < expired[j]
+	})
+
+	for _, sequence := range expired {
+		r, ok := t.complete(sequence, nil, err)
+		switch {
+		case !ok:
+
+		case err == ErrResponseTimeout && r.forget:
+
+		case err == ErrResponseTimeout:
+			t.onResponseTimeout(r)
+
+		case r.lost != nil:
+			r.lost(r.req)
+		}
+	}
+}
+
+// onResponseTimeout notifies timeout and closes unresponsive connection
+// after Settings.MaxResponseTimeouts consecutive timeouts.
+func (t *transceivable) onResponseTimeout(r *pendingRequest) {
+	t.logger.Warn("response timeout", "command_id", r.id.String(), "sequence_number", r.sequence)
+	if t.settings.OnResponseTimeout != nil {
+		t.settings.OnResponseTimeout(r.req)
+	}
+
+	if n := atomic.AddInt32(&t.timeouts, 1); t.settings.MaxResponseTimeouts > 0 && n == int32(t.settings.MaxResponseTimeouts) {
+		t.logger.Error("SMSC is unresponsive, unbinding", "timeouts", n)
+		// output sends unbind before closing
+		t.out.closing(UnresponsiveClosing)
+	}
+}
+
+// minResponseTimeout returns the lowest configured response timeout, zero if none.
+func (t *transceivable) minResponseTimeout() (timeout time.Duration) {
+	timeout = t.settings.ResponseTimeout
+	for _, v := range t.settings.ResponseTimeouts {
+		if v > 0 && (timeout == 0 || v < timeout) {
+			timeout = v
+		}
 	}
 	return
 }
 
-func (t *transceivable) rateLimit(ctx context.Context) error {
+// rateLimit waits for rate limiter, adaptive rate if p is message and shared limiter,
+// until ctx is done or transceiver is closed. Waiting is limited to one minute if ctx has no deadline.
+func (t *transceivable) rateLimit(ctx context.Context, p pdu.PDU) error {
+	limiters := make([]*rate.Limiter, 0, 2)
 	if t.rateLimiter != nil {
-		ctxLimiter, cancelLimiter := context.WithTimeout(ctx, time.Minute)
-		defer cancelLimiter()
-		if err := t.rateLimiter.Wait(ctxLimiter); err != nil {
-			return errors.Errorf("SMPP limiter failed: %v", err)
+		limiters = append(limiters, t.rateLimiter)
+	}
+	if t.adaptive != nil && isMessage(p) {
+		limiters = append(limiters, t.adaptive.limiter)
+	}
+	if len(limiters) == 0 && t.settings.Limiter == nil {
+		return nil
+	}
+
+	var cancel context.CancelFunc
+	if _, ok := ctx.Deadline(); ok {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithTimeout(ctx, time.Minute)
+	}
+	defer cancel()
+
+	// closing transceiver stops waiting
+	go func() {
+		select {
+		case <-t.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	start := time.Now()
+	err := waitLimiters(ctx, limiters)
+	if err == nil && t.settings.Limiter != nil {
+		err = t.settings.Limiter.Wait(ctx, p)
+	}
+	t.metrics.LimiterWait(t.settings.sessionID, time.Since(start))
+
+	if err != nil {
+		if t.ctx.Err() != nil {
+			return ErrConnectionClosing
 		}
+		return fmt.Errorf("SMPP limiter failed: %w", err)
 	}
 	return nil
 }
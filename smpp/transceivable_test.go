@@ -1,6 +1,7 @@
 package smpp
 
 import (
+	"context"
 	"github.com/sujit-baniya/protocol/smpp/coding"
 	"sync/atomic"
 	"testing"
@@ -8,9 +9,11 @@ import (
 
 	"github.com/sujit-baniya/protocol/smpp/data"
 	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 var (
@@ -83,7 +86,7 @@ func TestTRXSubmitSM(t *testing.T) {
 		_ = trans.Close()
 	}()
 
-	require.Equal(t, "MelroseLabsSMSC", trans.Transceiver().SystemID())
+	require.Equal(t, smsctest.DefaultSystemID, trans.Transceiver().SystemID())
 
 	// sending 20 SMS
 	for i := 0; i < 20; i++ {
@@ -128,3 +131,353 @@ func newSubmitSM(systemID string) *pdu.SubmitSM {
 
 	return submitSM
 }
+
+func TestTRXSubmitAsync(t *testing.T) {
+	newSession := func(t *testing.T, smsc *smsctest.Server, settings Settings) *Session {
+		settings.ReadTimeout = time.Second
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), settings, -1)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = session.Close()
+		})
+		return session
+	}
+
+	t.Run("pipelined", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		var unmatched int32
+		trx := newSession(t, smsc, Settings{
+			WindowSize: 4,
+			OnPDU: func(pdu.PDU, bool) {
+				atomic.AddInt32(&unmatched, 1)
+			},
+		}).AsyncSubmitter()
+
+		results := make([]<-chan Result, 0, 20)
+		for i := 0; i < 20; i++ {
+			results = append(results, trx.SubmitAsync(newSubmitSM("123456")))
+		}
+
+		for _, result := range results {
+			r := <-result
+			require.NoError(t, r.Err)
+			require.Equal(t, r.Request.GetSequenceNumber(), r.Response.GetSequenceNumber())
+			require.NotEmpty(t, r.Response.(*pdu.SubmitSMResp).MessageID)
+		}
+		require.Zero(t, atomic.LoadInt32(&unmatched))
+	})
+
+	t.Run("window is full", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		trx := newSession(t, smsc, Settings{WindowSize: 2}).AsyncSubmitter()
+
+		smsc.Stall()
+		first, second := trx.SubmitAsync(newSubmitSM("123456")), trx.SubmitAsync(newSubmitSM("123456"))
+
+		blocked := make(chan (<-chan Result), 1)
+		go func() {
+			blocked <- trx.SubmitAsync(newSubmitSM("123456"))
+		}()
+
+		select {
+		case <-blocked:
+			t.Fatal("window is not respected")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		smsc.Resume()
+		require.NoError(t, (<-first).Err)
+		require.NoError(t, (<-second).Err)
+		require.NoError(t, (<-<-blocked).Err)
+	})
+
+	t.Run("response timeout", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		expired := make(chan pdu.PDU, 1)
+		trx := newSession(t, smsc, Settings{
+			WindowSize:      1,
+			ResponseTimeout: 50 * time.Millisecond,
+			OnResponseTimeout: func(req pdu.PDU) {
+				expired <- req
+			},
+		}).AsyncSubmitter()
+
+		smsc.Stall()
+		req := newSubmitSM("123456")
+		r := <-trx.SubmitAsync(req)
+		require.Equal(t, ErrResponseTimeout, r.Err)
+		require.Nil(t, r.Response)
+		require.Equal(t, req, <-expired)
+
+		// window is released
+		smsc.Resume()
+		require.NoError(t, (<-trx.SubmitAsync(newSubmitSM("123456"))).Err)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		trx := newSession(t, smsc, Settings{}).AsyncSubmitter()
+
+		smsc.Stall()
+		result := trx.SubmitAsync(newSubmitSM("123456"))
+		require.NoError(t, trx.Close())
+		require.Equal(t, ErrConnectionClosing, (<-result).Err)
+		require.Equal(t, ErrConnectionClosing, (<-trx.SubmitAsync(newSubmitSM("123456"))).Err)
+	})
+}
+
+func TestTRXResponseTimeout(t *testing.T) {
+	newSession := func(t *testing.T, smsc *smsctest.Server, settings Settings) *Session {
+		settings.ReadTimeout = time.Second
+		// requests are written while SMSC stalls, unlike over synchronous pipe of smsc.Dial
+		session, err := NewSession(TRXConnector(NonTLSDialer, Auth{SMSC: smsc.Addr()}), settings, 100*time.Millisecond)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = session.Close()
+		})
+		return session
+	}
+
+	t.Run("per command", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		expired := make(chan pdu.PDU, 2)
+		trx := newSession(t, smsc, Settings{
+			ResponseTimeout: time.Minute,
+			ResponseTimeouts: map[data.CommandIDType]time.Duration{
+				data.SUBMIT_SM: 50 * time.Millisecond,
+			},
+			OnResponseTimeout: func(req pdu.PDU) {
+				expired <- req
+			},
+		}).Transceiver()
+
+		smsc.Stall()
+		req := newSubmitSM("123456")
+		require.NoError(t, trx.Submit(req))
+
+		select {
+		case p := <-expired:
+			require.Equal(t, req, p)
+		case <-time.After(time.Second):
+			t.Fatal("request submitted via Submit did not expire")
+		}
+	})
+
+	t.Run("deadline starts once written", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		trx := newSession(t, smsc, Settings{
+			ResponseTimeout: 50 * time.Millisecond,
+			Limiter: LimiterFunc(func(ctx context.Context, p pdu.PDU) error {
+				return sleep(ctx, 150*time.Millisecond)
+			}),
+		}).Transceiver()
+
+		resp, err := trx.SubmitResp(context.Background(), newSubmitSM("123456"))
+		require.NoError(t, err)
+		require.Equal(t, data.SUBMIT_SM_RESP, resp.GetHeader().CommandID)
+	})
+
+	t.Run("Submit is tracked for bounded time", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		var timeouts int32
+		unmatched := make(chan pdu.PDU, 1)
+		trx := newSession(t, smsc, Settings{
+			OnResponseTimeout: func(pdu.PDU) {
+				atomic.AddInt32(&timeouts, 1)
+			},
+			OnUnmatchedResponse: func(resp pdu.PDU) {
+				unmatched <- resp
+			},
+		}).bound()
+
+		smsc.Stall()
+		require.NoError(t, trx.Submit(newSubmitSM("123456")))
+
+		var deadline time.Time
+		require.Eventually(t, func() bool {
+			trx.mutex.Lock()
+			defer trx.mutex.Unlock()
+			for _, r := range trx.pending {
+				deadline = r.deadline
+			}
+			return !deadline.IsZero()
+		}, time.Second, 10*time.Millisecond)
+		require.WithinDuration(t, time.Now().Add(DefaultSubmitTracking), deadline, time.Second)
+
+		// forgotten quietly
+		trx.expire(func(*pendingRequest) bool { return true }, ErrResponseTimeout)
+		smsc.Resume()
+
+		select {
+		case resp := <-unmatched:
+			require.Equal(t, data.SUBMIT_SM_RESP, resp.GetHeader().CommandID)
+		case <-time.After(time.Second):
+			t.Fatal("late response is not unmatched")
+		}
+		require.Zero(t, atomic.LoadInt32(&timeouts))
+	})
+
+	t.Run("unbind after consecutive timeouts", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		closed := make(chan State, 1)
+		trx := newSession(t, smsc, Settings{
+			ResponseTimeout:     50 * time.Millisecond,
+			MaxResponseTimeouts: 2,
+			OnClosed: func(state State) {
+				// receiver might notice closed connection first
+				if state == InvalidStreaming {
+					return
+				}
+				select {
+				case closed <- state:
+				default:
+				}
+			},
+		}).AsyncSubmitter()
+
+		smsc.StallFor(200 * time.Millisecond)
+		require.Equal(t, ErrResponseTimeout, (<-trx.SubmitAsync(newSubmitSM("123456"))).Err)
+		require.Equal(t, ErrResponseTimeout, (<-trx.SubmitAsync(newSubmitSM("123456"))).Err)
+
+		require.Equal(t, UnresponsiveClosing, <-closed)
+		require.Eventually(t, func() bool {
+			return len(smsc.ReceivedOf(data.UNBIND)) == 1 && len(smsc.ReceivedOf(data.BIND_TRANSCEIVER)) == 2
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("timeouts are reset by response", func(t *testing.T) {
+		smsc := smsctest.NewServer(smsctest.Config{})
+		defer smsc.Close()
+
+		var closed int32
+		trx := newSession(t, smsc, Settings{
+			ResponseTimeout:     50 * time.Millisecond,
+			MaxResponseTimeouts: 2,
+			OnClosed: func(State) {
+				atomic.AddInt32(&closed, 1)
+			},
+		}).AsyncSubmitter()
+
+		for i := 0; i < 2; i++ {
+			smsc.StallFor(100 * time.Millisecond)
+			require.Equal(t, ErrResponseTimeout, (<-trx.SubmitAsync(newSubmitSM("123456"))).Err)
+			require.Eventually(t, func() bool {
+				return (<-trx.SubmitAsync(newSubmitSM("123456"))).Err == nil
+			}, time.Second, 10*time.Millisecond)
+		}
+		require.Zero(t, atomic.LoadInt32(&closed))
+	})
+}
+
+func TestTRXGenericNack(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	var received int32
+	session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+		ReadTimeout: time.Second,
+		OnPDU: func(pdu.PDU, bool) {
+			atomic.AddInt32(&received, 1)
+		},
+	}, -1)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+
+	// unknown command
+	require.NoError(t, smsc.SendRaw([]byte{
+		0x00, 0x00, 0x00, 0x14,
+		0x00, 0x00, 0x09, 0x99,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x07,
+		0x01, 0x02, 0x03, 0x04,
+	}))
+
+	require.Eventually(t, func() bool {
+		return len(smsc.ReceivedOf(data.GENERIC_NACK)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	nack := smsc.ReceivedOf(data.GENERIC_NACK)[0]
+	require.Equal(t, data.ESME_RINVCMDID, nack.GetHeader().CommandStatus)
+	require.Equal(t, int32(7), nack.GetSequenceNumber())
+	require.Zero(t, atomic.LoadInt32(&received))
+
+	// known request is left to OnPDU, even if SMSC is not expected to issue it
+	req := pdu.NewSubmitSM()
+	req.SetSequenceNumber(8)
+	require.NoError(t, smsc.Send(req))
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&received) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.Len(t, smsc.ReceivedOf(data.GENERIC_NACK), 1)
+
+	// stream is still valid
+	resp, err := session.Transceiver().SubmitResp(context.Background(), pdu.NewEnquireLink())
+	require.NoError(t, err)
+	require.Equal(t, data.ENQUIRE_LINK_RESP, resp.GetHeader().CommandID)
+}
+
+func TestTRXSubmitContext(t *testing.T) {
+	smsc := smsctest.NewServer(smsctest.Config{})
+	defer smsc.Close()
+
+	limiter := rate.NewLimiter(rate.Every(30*time.Second), 1)
+	session, err := NewSessionContext(context.Background(), TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr()}), Settings{
+		ReadTimeout: time.Second,
+		RateLimiter: limiter,
+		OnPDU:       func(pdu.PDU, bool) {},
+	}, -1)
+	require.NoError(t, err)
+	defer func() {
+		_ = session.Close()
+	}()
+	trx := session.AsyncSubmitter()
+
+	// consumes the only token
+	require.NoError(t, trx.SubmitContext(context.Background(), newSubmitSM("123456")))
+
+	t.Run("throttled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		err := trx.SubmitContext(ctx, newSubmitSM("123456"))
+		require.ErrorIs(t, err, context.Canceled)
+
+		ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = trx.SubmitResp(ctx, newSubmitSM("123456"))
+		require.Error(t, err)
+	})
+
+	t.Run("closed while throttled", func(t *testing.T) {
+		time.AfterFunc(50*time.Millisecond, func() {
+			_ = trx.Close()
+		})
+
+		err := trx.SubmitContext(context.Background(), newSubmitSM("123456"))
+		require.ErrorIs(t, err, ErrConnectionClosing)
+	})
+
+	require.Eventually(t, func() bool {
+		return len(smsc.ReceivedOf(data.SUBMIT_SM)) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -1,6 +1,7 @@
 package smpp
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"runtime"
@@ -8,6 +9,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/sujit-baniya/protocol/smpp/data"
 	"github.com/sujit-baniya/protocol/smpp/pdu"
 )
 
@@ -16,23 +18,40 @@ var (
 	ErrConnectionClosing = fmt.Errorf("connection is closing, can not send PDU to SMSC")
 )
 
+// queuedPDU is PDU waiting for transmitter, along with context of submitting.
+type queuedPDU struct {
+	ctx context.Context
+	p   pdu.PDU
+}
+
 type transmittable struct {
 	settings Settings
 
 	wg    sync.WaitGroup
-	input chan pdu.PDU
+	input chan queuedPDU
+	batch []pdu.PDU            // reused by batched writes
+	ids   []data.CommandIDType // reused by batched writes
+	seqs  []int32              // reused by batched writes
 
-	conn *Connection
+	conn    *Connection
+	metrics Metrics
 
 	aliveState   int32
 	pendingWrite int32
+	unbound      int32 // unbind is already exchanged, not sent again on close
 }
 
 func newTransmittable(conn *Connection, settings Settings) *transmittable {
+	queue := 1
+	if settings.WriteBatch != nil {
+		queue = settings.WriteBatch.maxPDUs()
+	}
+
 	t := &transmittable{
 		settings:     settings,
 		conn:         conn,
-		input:        make(chan pdu.PDU, 1),
+		metrics:      metricsOf(&settings),
+		input:        make(chan queuedPDU, queue),
 		aliveState:   Alive,
 		pendingWrite: 0,
 	}
@@ -53,7 +72,11 @@ func (t *transmittable) close(state State) (err error) {
 		t.wg.Wait()
 
 		// try to send unbind
-		_, _ = t.write(pdu.NewUnbind())
+		if atomic.LoadInt32(&t.unbound) == 0 {
+			unbind := pdu.NewUnbind()
+			t.conn.AssignSequenceNumber(unbind)
+			_, _ = t.write(unbind)
+		}
 
 		// close connection
 		if state != StoppingProcessOnly {
@@ -69,6 +92,11 @@ func (t *transmittable) close(state State) (err error) {
 	return
 }
 
+// skipUnbind on close, since unbind is exchanged already.
+func (t *transmittable) skipUnbind() {
+	atomic.StoreInt32(&t.unbound, 1)
+}
+
 func (t *transmittable) closing(state State) {
 	go func() {
 		_ = t.close(state)
@@ -77,10 +105,20 @@ func (t *transmittable) closing(state State) {
 
 // Submit a PDU.
 func (t *transmittable) Submit(p pdu.PDU) (err error) {
+	return t.SubmitContext(context.Background(), p)
+}
+
+// SubmitContext enqueues a PDU, giving up once ctx is done.
+// PDU still queued once ctx is done is not written, but reported to OnSubmitError with ctx.Err().
+func (t *transmittable) SubmitContext(ctx context.Context, p pdu.PDU) (err error) {
 	atomic.AddInt32(&t.pendingWrite, 1)
 
 	if atomic.LoadInt32(&t.aliveState) == Alive {
-		t.input <- p
+		select {
+		case t.input <- queuedPDU{ctx: ctx, p: p}:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
 	} else {
 		err = ErrConnectionClosing
 	}
@@ -104,20 +142,21 @@ func (t *transmittable) start() {
 	}
 }
 
+// drain reports PDUs left queued by closing transmitter to OnSubmitError with ErrConnectionClosing.
 func (t *transmittable) drain() {
-	for range t.input {
+	for q := range t.input {
+		if q.p != nil && t.settings.OnSubmitError != nil {
+			t.settings.OnSubmitError(q.p, ErrConnectionClosing)
+		}
 	}
 }
 
 func (t *transmittable) loop() {
 	defer t.drain()
 
-	for p := range t.input {
-		if p != nil {
-			n, err := t.write(p)
-			if t.check(p, n, err) {
-				return
-			}
+	for q := range t.input {
+		if t.send(q) {
+			return
 		}
 	}
 }
@@ -133,26 +172,37 @@ func (t *transmittable) loopWithEnquireLink() {
 		select {
 		case <-ticker.C:
 			eqp := pdu.NewEnquireLink()
+			t.conn.AssignSequenceNumber(eqp)
 			n, err := t.write(eqp)
 			if t.check(eqp, n, err) {
 				return
 			}
 
-		case p, ok := <-t.input:
+		case q, ok := <-t.input:
 			if !ok {
 				return
 			}
 
-			if p != nil {
-				n, err := t.write(p)
-				if t.check(p, n, err) {
-					return
-				}
+			if t.send(q) {
+				return
 			}
 		}
 	}
 }
 
+// expired reports queued PDU whose context of submitting is done, instead of writing it.
+func (t *transmittable) expired(q queuedPDU) bool {
+	err := q.ctx.Err()
+	if err == nil {
+		return false
+	}
+
+	if t.settings.OnSubmitError != nil {
+		t.settings.OnSubmitError(q.p, err)
+	}
+	return true
+}
+
 // check error and do closing if need
 func (t *transmittable) check(p pdu.PDU, n int, err error) (closing bool) {
 	if err == nil {
@@ -186,9 +236,19 @@ func (t *transmittable) write(p pdu.PDU) (n int, err error) {
 		err = t.conn.SetWriteTimeout(t.settings.WriteTimeout)
 	}
 
+	// PDU might be reused once written, e.g. requeued by adaptive rate
+	id, sequence := p.GetHeader().CommandID, p.GetSequenceNumber()
+
 	if err == nil {
 		n, err = t.conn.WritePDU(p)
 	}
 
+	if err == nil {
+		t.metrics.PDUSent(t.settings.sessionID, id)
+		if t.settings.written != nil {
+			t.settings.written(sequence)
+		}
+	}
+
 	return
 }
@@ -1,6 +1,8 @@
 package smpp
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"net"
 	"sync"
@@ -9,6 +11,7 @@ import (
 	"time"
 
 	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -43,7 +46,7 @@ func TestTransmit(t *testing.T) {
 			_ = transmitter.Close()
 		}()
 
-		require.Equal(t, "MelroseLabsSMSC", transmitter.Transmitter().SystemID())
+		require.Equal(t, smsctest.DefaultSystemID, transmitter.Transmitter().SystemID())
 
 		err = transmitter.Transmitter().Submit(newSubmitSM(auth.SystemID))
 		require.Nil(t, err)
@@ -56,11 +59,11 @@ func TestTransmit(t *testing.T) {
 	})
 
 	errorHandling := func(t *testing.T, trigger func(*transmittable)) {
-		conn, err := net.Dial("tcp", "smscsim.melroselabs.com:2775")
+		conn, err := net.Dial("tcp", smscAddr)
 		require.Nil(t, err)
 
 		var tr transmittable
-		tr.input = make(chan pdu.PDU, 1)
+		tr.input = make(chan queuedPDU, 1)
 
 		c := NewConnection(conn)
 		defer func() {
@@ -74,6 +77,7 @@ func TestTransmit(t *testing.T) {
 
 		// fake settings
 		tr.conn = c
+		tr.metrics = NopMetrics{}
 
 		var count int32
 		tr.settings.OnClosed = func(State) {
@@ -112,7 +116,7 @@ func TestTransmit(t *testing.T) {
 
 	t.Run("SubmitErr", func(t *testing.T) {
 		var tr transmittable
-		tr.input = make(chan pdu.PDU, 1)
+		tr.input = make(chan queuedPDU, 1)
 
 		tr.aliveState = 1
 		err := tr.Submit(nil)
@@ -131,10 +135,6 @@ func TestConcurrentSubmitClose(t *testing.T) {
 		Settings{
 			ReadTimeout: 2 * time.Second,
 
-			OnPDU: func(p pdu.PDU, _ bool) {
-				t.Logf("%+v\n", p)
-			},
-
 			OnSubmitError: func(_ pdu.PDU, err error) {
 				t.Fatal(err)
 			},
@@ -153,7 +153,7 @@ func TestConcurrentSubmitClose(t *testing.T) {
 		_ = transmitter.Close()
 	}()
 
-	require.Equal(t, "MelroseLabsSMSC", transmitter.Transmitter().SystemID())
+	require.Equal(t, smsctest.DefaultSystemID, transmitter.Transmitter().SystemID())
 
 	var wg sync.WaitGroup
 
@@ -184,3 +184,47 @@ func TestConcurrentSubmitClose(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestTransmitExpired(t *testing.T) {
+	client, server := net.Pipe()
+	defer func() {
+		_ = client.Close()
+		_ = server.Close()
+	}()
+
+	failed := make(chan error, 1)
+	tr := newTransmittable(NewConnection(client), Settings{
+		OnSubmitError: func(p pdu.PDU, err error) {
+			require.Equal(t, int32(2), p.GetSequenceNumber())
+			failed <- err
+		},
+	})
+	tr.skipUnbind()
+	tr.start()
+	defer func() {
+		_ = tr.close(StoppingProcessOnly)
+	}()
+
+	submit := func(ctx context.Context, sequence int32) {
+		p := pdu.NewEnquireLink()
+		p.SetSequenceNumber(sequence)
+		require.NoError(t, tr.SubmitContext(ctx, p))
+	}
+
+	// the first PDU is being written until server reads, the second is queued
+	ctx, cancel := context.WithCancel(context.Background())
+	submit(context.Background(), 1)
+	submit(ctx, 2)
+	cancel()
+
+	r := bufio.NewReader(server)
+	p, err := pdu.Parse(r)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), p.GetSequenceNumber())
+	require.ErrorIs(t, <-failed, context.Canceled)
+
+	submit(context.Background(), 3)
+	p, err = pdu.Parse(r)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), p.GetSequenceNumber())
+}
@@ -0,0 +1,42 @@
+package smpp
+
+import (
+	"fmt"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+)
+
+var (
+	// ErrUnsupportedInterfaceVersion indicates command requires newer interface version
+	// than negotiated with SMSC.
+	ErrUnsupportedInterfaceVersion = fmt.Errorf("command is not supported by negotiated interface version")
+)
+
+// smppV33 is interface version assumed for SMSC which does not report sc_interface_version.
+const smppV33 = byte(0x33)
+
+// negotiateInterfaceVersion returns interface version in use after binding: the lower of
+// requested version and sc_interface_version reported within bind_resp.
+//
+// SMSC omitting sc_interface_version is assumed to be v3.3, as specified.
+func negotiateInterfaceVersion(requested byte, resp *pdu.BindResp) byte {
+	field, ok := resp.OptionalParameters[pdu.TagScInterfaceVersion]
+	if !ok || len(field.Data) != 1 {
+		return smppV33
+	}
+
+	if supported := field.Data[0]; supported < requested {
+		return supported
+	}
+	return requested
+}
+
+// requiredInterfaceVersion returns the lowest interface version supporting PDU.
+func requiredInterfaceVersion(p pdu.PDU) byte {
+	switch p.GetHeader().CommandID {
+	case data.BROADCAST_SM, data.QUERY_BROADCAST_SM, data.CANCEL_BROADCAST_SM:
+		return data.SMPP_V50
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package smpp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sujit-baniya/protocol/smpp/data"
+	"github.com/sujit-baniya/protocol/smpp/pdu"
+	"github.com/sujit-baniya/protocol/smpp/smsctest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterfaceVersion(t *testing.T) {
+	negotiate := func(t *testing.T, supported, requested byte) byte {
+		smsc := smsctest.NewServer(smsctest.Config{InterfaceVersion: supported})
+		defer smsc.Close()
+
+		conn, err := TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr(), InterfaceVersion: requested}).Connect()
+		require.NoError(t, err)
+		_ = conn.Close()
+
+		binds := smsc.ReceivedOf(data.BIND_TRANSCEIVER)
+		require.Len(t, binds, 1)
+		if requested == 0 {
+			requested = data.SMPP_V34
+		}
+		require.Equal(t, requested, binds[0].(*pdu.BindRequest).InterfaceVersion)
+
+		return conn.InterfaceVersion()
+	}
+
+	require.Equal(t, data.SMPP_V50, negotiate(t, data.SMPP_V50, data.SMPP_V50))
+	require.Equal(t, data.SMPP_V34, negotiate(t, data.SMPP_V50, 0))
+	require.Equal(t, data.SMPP_V34, negotiate(t, data.SMPP_V34, data.SMPP_V50))
+
+	// sc_interface_version is not reported
+	require.Equal(t, byte(0x33), negotiate(t, 0, data.SMPP_V50))
+}
+
+func TestBroadcastSM(t *testing.T) {
+	newTransceiver := func(t *testing.T, supported byte) AsyncSubmitter {
+		smsc := smsctest.NewServer(smsctest.Config{
+			InterfaceVersion: supported,
+			MessageID: func(pdu.PDU) string {
+				return "bc-1"
+			},
+		})
+		t.Cleanup(smsc.Close)
+
+		session, err := NewSession(TRXConnector(smsc.Dial, Auth{SMSC: smsc.Addr(), InterfaceVersion: data.SMPP_V50}), Settings{
+			ReadTimeout: time.Second,
+		}, time.Second)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = session.Close()
+		})
+		return session.AsyncSubmitter()
+	}
+
+	newBroadcastSM := func() *pdu.BroadcastSM {
+		p := pdu.NewBroadcastSM().(*pdu.BroadcastSM)
+		p.Areas = []pdu.BroadcastArea{{Format: data.BROADCAST_AREA_FORMAT_ALIAS, Details: []byte("north")}}
+		p.RegisterOptionalParam(pdu.NewBroadcastContentType(data.BROADCAST_NETWORK_GSM, 1))
+		p.RegisterOptionalParam(pdu.NewBroadcastRepNum(1))
+		p.RegisterOptionalParam(pdu.NewBroadcastFrequencyInterval(data.BROADCAST_FREQ_MINUTES, 5))
+		return p
+	}
+
+	t.Run("v5", func(t *testing.T) {
+		trx := newTransceiver(t, data.SMPP_V50)
+		require.Equal(t, data.SMPP_V50, trx.InterfaceVersion())
+
+		resp, err := trx.SubmitResp(context.Background(), newBroadcastSM())
+		require.NoError(t, err)
+		require.Equal(t, "bc-1", resp.(*pdu.BroadcastSMResp).MessageID)
+
+		query := pdu.NewQueryBroadcastSM().(*pdu.QueryBroadcastSM)
+		query.MessageID = "bc-1"
+		resp, err = trx.SubmitResp(context.Background(), query)
+		require.NoError(t, err)
+		require.IsType(t, &pdu.QueryBroadcastSMResp{}, resp)
+
+		cancel := pdu.NewCancelBroadcastSM().(*pdu.CancelBroadcastSM)
+		cancel.MessageID = "bc-1"
+		result := <-trx.SubmitAsync(cancel)
+		require.NoError(t, result.Err)
+		require.IsType(t, &pdu.CancelBroadcastSMResp{}, result.Response)
+	})
+
+	t.Run("v3.4", func(t *testing.T) {
+		trx := newTransceiver(t, data.SMPP_V34)
+		require.Equal(t, data.SMPP_V34, trx.InterfaceVersion())
+
+		_, err := trx.SubmitResp(context.Background(), newBroadcastSM())
+		require.ErrorIs(t, err, ErrUnsupportedInterfaceVersion)
+		require.ErrorIs(t, trx.Submit(newBroadcastSM()), ErrUnsupportedInterfaceVersion)
+		require.ErrorIs(t, (<-trx.SubmitAsync(newBroadcastSM())).Err, ErrUnsupportedInterfaceVersion)
+
+		// v3.4 commands are still allowed
+		_, err = trx.SubmitResp(context.Background(), pdu.NewEnquireLink())
+		require.NoError(t, err)
+	})
+}